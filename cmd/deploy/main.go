@@ -10,9 +10,22 @@ import (
 )
 
 func main() {
+	var code deployer.ExitCode
+	var err error
+
 	cfg := deployer.NewConfig()
 	d := deployer.Deployer{Client: http.DefaultClient, DeployServer: cfg.DeployServerURL}
-	code, err := d.Run(cfg)
+
+	switch flag.Arg(0) {
+	case "status":
+		code, err = d.Status(cfg, flag.Arg(1))
+	case "history":
+		code, err = d.History(cfg, flag.Arg(1))
+	case "cancel":
+		code, err = d.Cancel(cfg, flag.Arg(1))
+	default:
+		code, err = d.Run(cfg)
+	}
 
 	if err != nil {
 		if code == deployer.ExitInvocationFailure {
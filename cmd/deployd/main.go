@@ -19,6 +19,8 @@ import (
 	"github.com/navikt/deployment/pkg/deployd/deployd"
 	"github.com/navikt/deployment/pkg/deployd/kubeclient"
 	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"github.com/navikt/deployment/pkg/deployd/outbox"
+	"github.com/navikt/deployment/pkg/deployd/strategy"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -26,6 +28,17 @@ import (
 
 const (
 	requestBackoff = 2 * time.Second
+
+	// Deployment requests might be redelivered if the acknowledgement is lost.
+	// Remember received requests for this long to avoid deploying them twice.
+	deliveryMemory = 10 * time.Minute
+
+	// How long to keep trying to deliver deployment statuses on shutdown.
+	shutdownTimeout = 10 * time.Second
+
+	// How long to wait for hookd to record an acknowledgement. Requests are received
+	// one at a time, so a hanging hookd must not hold up the deployment stream.
+	acknowledgeTimeout = 5 * time.Second
 )
 
 var maskedConfig = []string{
@@ -53,12 +66,37 @@ func run() error {
 		return fmt.Errorf("authenticated gRPC calls enabled, but --hookd-application-id is not specified")
 	}
 
+	if cfg.DiscoveryInterval <= 0 {
+		return fmt.Errorf("--discovery-interval must be positive")
+	}
+
 	kube, err := kubeclient.New()
 	if err != nil {
 		return fmt.Errorf("cannot configure Kubernetes client: %s", err)
 	}
 	log.Infof("kubernetes..............: %s", kube.Config.Host)
 
+	kube.ReadinessRules, err = strategy.ParseReadinessRules(cfg.ReadinessRules)
+	if err != nil {
+		return err
+	}
+	kube.DefaultReadinessRule = strategy.ParseReadinessRule(cfg.DefaultReadinessRule)
+	kube.DiagnosisLogs = cfg.DiagnosisLogs
+
+	kube.TeamAuth = kubeclient.TeamAuth{
+		Mode:              cfg.TeamAuth,
+		ImpersonateUser:   cfg.ImpersonateUser,
+		ImpersonateGroups: cfg.ImpersonateGroups,
+		TokenExpiration:   cfg.TokenExpiration,
+	}
+	if err := kube.TeamAuth.Validate(); err != nil {
+		return fmt.Errorf("--team-auth: %s", err)
+	}
+	log.Infof("team authentication.....: %s", cfg.TeamAuth)
+
+	kube.TeamClientTTL = cfg.TeamClientTTL
+	go kube.RESTMapper.Run(context.Background(), cfg.DiscoveryInterval)
+
 	statusChan := make(chan *pb.DeploymentStatus, 1024)
 
 	metricsServer := http.NewServeMux()
@@ -102,15 +140,36 @@ func run() error {
 
 	defer grpcConnection.Close()
 
+	statuses, err := outbox.New(func(ctx context.Context, status *pb.DeploymentStatus) error {
+		_, err := grpcClient.ReportStatus(ctx, status)
+		return err
+	}, cfg.StatusSpool)
+	if err != nil {
+		return err
+	}
+
+	instance, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("unable to determine instance name: %s", err)
+	}
+
 	// Trap SIGINT to trigger a shutdown.
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt)
+
+	deployments := deployd.NewDeployments()
+	scheduler := deployd.NewScheduler(cfg.Concurrency)
+
 	go func() {
+		received := make(map[string]time.Time)
+		resumed := false
+
 		for {
 			time.Sleep(requestBackoff)
 
 			deploymentStream, err := grpcClient.Deployments(context.Background(), &pb.GetDeploymentOpts{
-				Cluster: cfg.Cluster,
+				Cluster:  cfg.Cluster,
+				Instance: instance,
 			})
 
 			if err != nil {
@@ -120,6 +179,23 @@ func run() error {
 
 			log.Infof("Connected to hookd and receiving deployment requests")
 
+			// Deployments interrupted by a restart are resumed once, as soon as hookd can be reached.
+			if !resumed {
+				unfinished, err := grpcClient.UnfinishedDeployments(context.Background(), &pb.GetDeploymentOpts{
+					Cluster:  cfg.Cluster,
+					Instance: instance,
+				})
+				if err != nil {
+					log.Errorf("Retrieve unfinished deployments: %s", err)
+				} else {
+					resumed = true
+					for _, req := range unfinished.GetRequests() {
+						received[req.GetDeliveryID()] = time.Now()
+						deployd.Resume(deployments.Start(req.GetDeliveryID()), log.WithFields(req.LogFields()), req, *cfg, kube, scheduler, statusChan)
+					}
+				}
+			}
+
 			for {
 				req, err := deploymentStream.Recv()
 				if err != nil {
@@ -127,7 +203,40 @@ func run() error {
 					break
 				} else {
 					logger := log.WithFields(req.LogFields())
-					deployd.Run(logger, req, *cfg, kube, statusChan)
+
+					// Cancellations are fire-and-forget, and need no acknowledgement.
+					if req.GetCancel() {
+						if deployments.Stop(req.GetDeliveryID()) {
+							logger.Infof("Cancelling deployment")
+						} else {
+							logger.Warnf("Ignoring cancellation of unknown or finished deployment")
+						}
+						continue
+					}
+
+					ctx, cancel := context.WithTimeout(context.Background(), acknowledgeTimeout)
+					_, err = grpcClient.Acknowledge(ctx, &pb.Acknowledgement{
+						DeliveryID: req.GetDeliveryID(),
+						Cluster:    cfg.Cluster,
+						Instance:   instance,
+					})
+					cancel()
+					if err != nil {
+						logger.Errorf("Acknowledge deployment request: %s", err)
+					}
+
+					for id, t := range received {
+						if time.Since(t) > deliveryMemory {
+							delete(received, id)
+						}
+					}
+					if _, ok := received[req.GetDeliveryID()]; ok {
+						logger.Warnf("Ignoring redelivered deployment request")
+						continue
+					}
+					received[req.GetDeliveryID()] = time.Now()
+
+					deployd.Run(deployments.Start(req.GetDeliveryID()), logger, req, *cfg, kube, scheduler, statusChan)
 				}
 			}
 
@@ -149,22 +258,38 @@ func run() error {
 			case status.GetState() == pb.GithubDeploymentState_failure:
 				metrics.DeployFailed.Inc()
 				logger.Errorf(status.GetDescription())
+			case status.GetProgress():
+				logger.Infof(status.GetDescription())
+			case status.GetState() == pb.GithubDeploymentState_cancelled:
+				metrics.DeployCancelled.Inc()
+				logger.Warnf(status.GetDescription())
 			default:
 				metrics.DeploySuccessful.Inc()
 				logger.Infof(status.GetDescription())
 			}
 
-			_, err = grpcClient.ReportStatus(context.Background(), status)
-			if err != nil {
-				logger.Errorf("While reporting deployment status: %s", err)
-				statusChan <- status
-				time.Sleep(5 * time.Second)
-				break
-			} else {
-				logger.Infof("Deployment response sent successfully")
+			// Release the context of finished deployments.
+			if status.GetState().Finished() {
+				deployments.Stop(status.GetDeliveryID())
 			}
 
+			statuses.Add(status)
+
 		case <-signals:
+			for len(statusChan) > 0 {
+				if status := <-statusChan; status != nil {
+					statuses.Add(status)
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			defer cancel()
+
+			err := statuses.Flush(ctx)
+			if err != nil {
+				log.Warnf("Shutting down with undelivered statuses: %s", err)
+			}
+
 			return nil
 		}
 	}
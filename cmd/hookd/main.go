@@ -21,6 +21,7 @@ import (
 	"github.com/navikt/deployment/pkg/logging"
 	"github.com/navikt/deployment/pkg/pb"
 	"github.com/navikt/deployment/pkg/hookd/api"
+	api_v1 "github.com/navikt/deployment/pkg/hookd/api/v1"
 	"github.com/navikt/deployment/pkg/hookd/config"
 	"github.com/navikt/deployment/pkg/hookd/database"
 	"github.com/navikt/deployment/pkg/hookd/github"
@@ -58,6 +59,10 @@ func run() error {
 		return fmt.Errorf("--github-install-id and --github-app-id must be specified when --github-enabled=true")
 	}
 
+	if cfg.RolloutTimeout < cfg.RolloutTimeoutMin || cfg.RolloutTimeout > cfg.RolloutTimeoutMax {
+		return fmt.Errorf("--rollout-timeout must be between --rollout-timeout-min and --rollout-timeout-max")
+	}
+
 	provisionKey, err := hex.DecodeString(cfg.ProvisionKey)
 	if err != nil {
 		return fmt.Errorf("provisioning pre-shared key must be a hex encoded string")
@@ -103,7 +108,7 @@ func run() error {
 	graphAPIClient := graphapi.NewClient(cfg.Azure)
 
 	// Set up gRPC server
-	deployServer, err := startGrpcServer(*cfg, db, githubClient, certificates)
+	deployServer, err := startGrpcServer(*cfg, db, db, githubClient, certificates)
 	if err != nil {
 		return err
 	}
@@ -122,6 +127,11 @@ func run() error {
 		MetricsPath:                 cfg.MetricsPath,
 		OAuthKeyValidatorMiddleware: middleware.TokenValidatorMiddleware(certificates, cfg.Azure.ClientID),
 		ProvisionKey:                provisionKey,
+		RolloutTimeout: api_v1.RolloutTimeout{
+			Default: cfg.RolloutTimeout,
+			Min:     cfg.RolloutTimeoutMin,
+			Max:     cfg.RolloutTimeoutMax,
+		},
 		TeamClient:                  graphAPIClient,
 		TeamRepositoryStorage:       db,
 	})
@@ -142,8 +152,8 @@ func run() error {
 	return nil
 }
 
-func startGrpcServer(cfg config.Config, db database.DeploymentStore, githubClient github.Client, certificates map[string]discovery.CertificateList) (deployserver.DeployServer, error) {
-	deployServer := deployserver.New(db, githubClient)
+func startGrpcServer(cfg config.Config, db database.DeploymentStore, queue database.DeploymentRequestStore, githubClient github.Client, certificates map[string]discovery.CertificateList) (deployserver.DeployServer, error) {
+	deployServer := deployserver.New(db, queue, githubClient, cfg.AckTimeout)
 	serverOpts := make([]grpc.ServerOption, 0)
 	if cfg.GrpcAuthentication {
 		preAuthApps := make([]oauth2.PreAuthorizedApplication, 0)
@@ -18,6 +18,7 @@ require (
 	github.com/imdario/mergo v0.3.8 // indirect
 	github.com/jackc/pgx/v4 v4.5.0
 	github.com/mitchellh/mapstructure v1.1.2
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/prometheus/client_golang v1.2.1
 	github.com/prometheus/procfs v0.0.8 // indirect
 	github.com/sirupsen/logrus v1.4.2
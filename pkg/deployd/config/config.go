@@ -2,24 +2,39 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	LogFormat                string `json:"log-format"`
-	LogLevel                 string `json:"log-level"`
-	Cluster                  string `json:"cluster"`
-	MetricsListenAddr        string `json:"metrics-listen-address"`
-	GrpcAuthentication       bool   `json:"grpc-authentication"`
-	GrpcUseTLS               bool   `json:"grpc-use-tls"`
-	GrpcServer               string `json:"grpc-server"`
-	HookdApplicationID       string `json:"hookd-application-id"`
-	MetricsPath              string `json:"metrics-path"`
-	TeamNamespaces           bool   `json:"team-namespaces"`
-	AutoCreateServiceAccount bool   `json:"auto-create-service-account"`
-	Azure                    Azure  `json:"azure"`
+	LogFormat                string        `json:"log-format"`
+	LogLevel                 string        `json:"log-level"`
+	Cluster                  string        `json:"cluster"`
+	MetricsListenAddr        string        `json:"metrics-listen-address"`
+	GrpcAuthentication       bool          `json:"grpc-authentication"`
+	GrpcUseTLS               bool          `json:"grpc-use-tls"`
+	GrpcServer               string        `json:"grpc-server"`
+	HookdApplicationID       string        `json:"hookd-application-id"`
+	MetricsPath              string        `json:"metrics-path"`
+	TeamNamespaces           bool          `json:"team-namespaces"`
+	AutoCreateServiceAccount bool          `json:"auto-create-service-account"`
+	TeamAuth                 string        `json:"team-auth"`
+	ImpersonateUser          string        `json:"impersonate-user"`
+	ImpersonateGroups        []string      `json:"impersonate-groups"`
+	TokenExpiration          time.Duration `json:"token-expiration"`
+	ServerSideApply          bool          `json:"server-side-apply"`
+	RollbackTeams            []string      `json:"rollback-teams"`
+	ReadinessRules           []string      `json:"readiness-rules"`
+	DefaultReadinessRule     string        `json:"default-readiness-rule"`
+	DiagnosisLogs            bool          `json:"diagnosis-logs"`
+	PreflightPermissions     bool          `json:"preflight-permissions"`
+	Concurrency              int           `json:"concurrency"`
+	StatusSpool              string        `json:"status-spool"`
+	TeamClientTTL            time.Duration `json:"team-client-ttl"`
+	DiscoveryInterval        time.Duration `json:"discovery-interval"`
+	Azure                    Azure         `json:"azure"`
 }
 
 type Azure struct {
@@ -40,6 +55,20 @@ const (
 	MetricsPath              = "metrics-path"
 	TeamNamespaces           = "team-namespaces"
 	AutoCreateServiceAccount = "auto-create-service-account"
+	TeamAuth                 = "team-auth"
+	ImpersonateUser          = "impersonate-user"
+	ImpersonateGroups        = "impersonate-groups"
+	TokenExpiration          = "token-expiration"
+	ServerSideApply          = "server-side-apply"
+	RollbackTeams            = "rollback-teams"
+	ReadinessRules           = "readiness-rules"
+	DefaultReadinessRule     = "default-readiness-rule"
+	DiagnosisLogs            = "diagnosis-logs"
+	PreflightPermissions     = "preflight-permissions"
+	Concurrency              = "concurrency"
+	StatusSpool              = "status-spool"
+	TeamClientTTL            = "team-client-ttl"
+	DiscoveryInterval        = "discovery-interval"
 	AzureClientID            = "azure.app-client-id"
 	AzureClientSecret        = "azure.app-client-secret"
 	AzureTenant              = "azure.app-tenant-id"
@@ -68,6 +97,20 @@ func Initialize() *Config {
 	flag.String(MetricsPath, "/metrics", "Serve metrics on this endpoint.")
 	flag.Bool(TeamNamespaces, false, "Set to true if team service accounts live in team's own namespace.")
 	flag.Bool(AutoCreateServiceAccount, true, "Set to true to automatically create service accounts.")
+	flag.String(TeamAuth, "secret", "How to authenticate as a team: 'secret' uses the service account token secret, 'impersonate' uses deployd's own credentials impersonating the team, and 'token-request' uses short-lived service account tokens.")
+	flag.String(ImpersonateUser, "system:serviceaccount:{namespace}:serviceuser-{team}", "User to impersonate when team-auth is 'impersonate'. {team} and {namespace} are replaced with the team and the namespace of its service account.")
+	flag.StringSlice(ImpersonateGroups, []string{"system:serviceaccounts", "system:serviceaccounts:{namespace}"}, "Comma-separated list of groups to impersonate when team-auth is 'impersonate'. Supports the same placeholders as impersonate-user.")
+	flag.Duration(TokenExpiration, time.Hour, "Lifetime of service account tokens requested when team-auth is 'token-request'.")
+	flag.Bool(ServerSideApply, false, "Deploy resources using server-side apply, leaving fields managed by other controllers intact. Existing resources not yet applied server-side are taken over on their first deployment.")
+	flag.StringSlice(RollbackTeams, []string{}, "Comma-separated list of teams whose failed deployments are always rolled back to the previous version.")
+	flag.StringSlice(ReadinessRules, []string{}, "Comma-separated list of rules for monitoring custom resources, on the form KIND.GROUP=READY[/FAILED] where READY and FAILED are status condition types.")
+	flag.String(DefaultReadinessRule, "", "Readiness rule on the form READY[/FAILED], e.g. Ready/Stalled, for custom resources not covered by readiness-rules, applied if they report status conditions. If empty, such resources are not monitored.")
+	flag.Bool(DiagnosisLogs, false, "Include the last log lines of crashing containers when explaining a failed rollout. Deployment statuses are stored by hookd and synchronized to GitHub, so only enable this if application logs never contain sensitive data.")
+	flag.Bool(PreflightPermissions, true, "Check that the team is allowed to apply every resource before starting a deployment, and reject the deployment otherwise.")
+	flag.Int(Concurrency, 8, "Maximum number of deployments applied at the same time. Further deployments are queued. Rollouts are monitored without occupying a worker.")
+	flag.String(StatusSpool, "", "Directory where deployment statuses are kept until delivered to hookd, so that they survive a restart. If empty, statuses are only kept in memory.")
+	flag.Duration(TeamClientTTL, time.Minute*5, "Reuse team clients for this long before checking the service account token for changes.")
+	flag.Duration(DiscoveryInterval, time.Minute*10, "Refresh Kubernetes API discovery information at this interval.")
 	flag.String(AzureClientID, "", "Azure ClientId.")
 	flag.String(AzureClientSecret, "", "Azure ClientSecret")
 	flag.String(AzureTenant, "", "Azure Tenant")
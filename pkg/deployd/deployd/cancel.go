@@ -0,0 +1,46 @@
+package deployd
+
+import (
+	"context"
+	"sync"
+)
+
+// Deployments keeps track of running deployments by delivery ID, so that they can be cancelled.
+type Deployments struct {
+	lock    sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func NewDeployments() *Deployments {
+	return &Deployments{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// Start returns the context for a new deployment. The context is cancelled when the deployment is stopped.
+func (d *Deployments) Start(deliveryID string) context.Context {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancels[deliveryID] = cancel
+
+	return ctx
+}
+
+// Stop cancels the context of a deployment, and forgets about it.
+// Returns false if no such deployment is running.
+func (d *Deployments) Stop(deliveryID string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	cancel, ok := d.cancels[deliveryID]
+	if !ok {
+		return false
+	}
+
+	cancel()
+	delete(d.cancels, deliveryID)
+
+	return true
+}
@@ -1,8 +1,10 @@
 package deployd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/navikt/deployment/pkg/deployd/config"
 	"github.com/navikt/deployment/pkg/deployd/kubeclient"
 	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"github.com/navikt/deployment/pkg/deployd/strategy"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -41,6 +44,14 @@ func meetsDeadline(req pb.DeploymentRequest) error {
 	return nil
 }
 
+// The rollout timeout requested by the deployment, or the default if none was specified.
+func rolloutTimeout(req *pb.DeploymentRequest) time.Duration {
+	if timeout := req.RolloutTimeout(); timeout > 0 {
+		return timeout
+	}
+	return deploymentTimeout
+}
+
 func jsonToResources(json []json.RawMessage) ([]unstructured.Unstructured, error) {
 	resources := make([]unstructured.Unstructured, len(json))
 	for i := range resources {
@@ -52,6 +63,31 @@ func jsonToResources(json []json.RawMessage) ([]unstructured.Unstructured, error
 	return resources, nil
 }
 
+// Decode the Kubernetes resources of a deployment request.
+func requestResources(req *pb.DeploymentRequest) ([]unstructured.Unstructured, error) {
+	rawResources, err := req.GetPayloadSpec().JSONResources()
+	if err != nil {
+		return nil, fmt.Errorf("unserializing kubernetes resources: %s", err)
+	}
+
+	if len(rawResources) == 0 {
+		return nil, fmt.Errorf("no resources to deploy")
+	}
+
+	return jsonToResources(rawResources)
+}
+
+// Set up a client acting on behalf of the team owning a deployment request.
+func newTeamClient(req *pb.DeploymentRequest, cfg config.Config, kube kubeclient.TeamClientProvider) (kubeclient.TeamClient, error) {
+	team := req.GetPayloadSpec().GetTeam()
+	namespace := DefaultTeamclientNamespace
+	if cfg.TeamNamespaces {
+		namespace = team
+	}
+
+	return kube.TeamClient(team, namespace, cfg.AutoCreateServiceAccount, cfg.ServerSideApply)
+}
+
 // Annotate a resource with the deployment correlation ID.
 func addCorrelationID(resource *unstructured.Unstructured, correlationID string) {
 	anno := resource.GetAnnotations()
@@ -80,9 +116,38 @@ func Prepare(req *pb.DeploymentRequest, cluster string) error {
 	return nil
 }
 
-func Run(logger *log.Entry, req *pb.DeploymentRequest, cfg config.Config, kube kubeclient.TeamClientProvider, deployStatus chan *pb.DeploymentStatus) {
-	var namespace string
+// Validate resources against the cluster without persisting them, and report the changes a deployment would make.
+func dryRun(logger *log.Entry, req *pb.DeploymentRequest, teamClient kubeclient.TeamClient, phases [][]indexedResource, deployStatus chan *pb.DeploymentStatus) {
+	diffs := make([]string, 0)
+	total := 0
+
+	for _, phase := range phases {
+		for _, r := range phase {
+			resource := r.resource
+			addCorrelationID(&resource, req.GetDeliveryID())
+
+			_, diff, err := teamClient.DeployUnstructured(resource, true)
+			if err != nil {
+				err = fmt.Errorf("resource %d: %s", r.index+1, err)
+				logger.Error(err)
+				deployStatus <- pb.NewFailureStatus(*req, err)
+				return
+			}
+
+			if len(diff) > 0 {
+				diffs = append(diffs, diff)
+			}
+			total++
+		}
+	}
+
+	deployStatus <- pb.NewDryRunStatus(*req, strings.Join(diffs, ""), len(diffs), total)
+}
 
+// Run validates a deployment request, and queues it for deployment to the cluster. Once the scheduler
+// has made room for it, the resources are applied, and the rollout status is reported on the deployStatus channel.
+// Run returns as soon as the request is queued. The deployment is aborted if the context is cancelled.
+func Run(ctx context.Context, logger *log.Entry, req *pb.DeploymentRequest, cfg config.Config, kube kubeclient.TeamClientProvider, scheduler *Scheduler, deployStatus chan *pb.DeploymentStatus) {
 	logger.Infof("Starting deployment")
 
 	// Check the validity of the message.
@@ -100,93 +165,273 @@ func Run(logger *log.Entry, req *pb.DeploymentRequest, cfg config.Config, kube k
 		return
 	}
 
-	p := req.GetPayloadSpec()
-	logger.Data["team"] = p.Team
+	logger.Data["team"] = req.GetPayloadSpec().GetTeam()
 
-	if cfg.TeamNamespaces {
-		namespace = p.Team
-	} else {
-		namespace = DefaultTeamclientNamespace
+	teamClient, err := newTeamClient(req, cfg, kube)
+	if err != nil {
+		deployStatus <- pb.NewErrorStatus(*req, err)
+		return
 	}
 
-	teamClient, err := kube.TeamClient(p.Team, namespace, cfg.AutoCreateServiceAccount)
+	resources, err := requestResources(req)
 	if err != nil {
 		deployStatus <- pb.NewErrorStatus(*req, err)
 		return
 	}
 
-	rawResources, err := p.JSONResources()
+	inventory, err := inventoryID(req)
 	if err != nil {
-		deployStatus <- pb.NewErrorStatus(*req, fmt.Errorf("unserializing kubernetes resources: %s", err))
+		deployStatus <- pb.NewErrorStatus(*req, err)
 		return
 	}
 
-	if len(rawResources) == 0 {
-		deployStatus <- pb.NewErrorStatus(*req, fmt.Errorf("no resources to deploy"))
+	// Deployments from the same repository share the default inventory, and would prune each other's resources.
+	if req.GetPrune() && len(req.GetInventory()) == 0 {
+		deployStatus <- pb.NewErrorStatus(*req, fmt.Errorf("pruning requires an explicit inventory to keep track of deployed resources"))
 		return
 	}
 
-	resources, err := jsonToResources(rawResources)
+	if len(inventory) > 0 {
+		logger.Data["inventory"] = inventory
+		for i := range resources {
+			addInventoryLabel(&resources[i], inventory)
+		}
+	}
+
+	deployPhases, err := phases(resources)
 	if err != nil {
 		deployStatus <- pb.NewErrorStatus(*req, err)
 		return
 	}
 
-	logger.Infof("Accepting incoming deployment request")
+	// Dry runs never change anything, and may run alongside deployments of the same objects.
+	locked := resources
+	if req.GetDryRun() {
+		locked = nil
+	}
 
-	wait := sync.WaitGroup{}
-	errors := make(chan error, len(resources))
+	logger.Infof("Queueing deployment request")
 
-	for index, resource := range resources {
-		addCorrelationID(&resource, req.GetDeliveryID())
+	// Reserving before returning makes deployments of the same objects run in the order they were received.
+	reservation := scheduler.Reserve(locked)
 
-		gvk := resource.GroupVersionKind().String()
-		ns := resource.GetNamespace()
-		n := resource.GetName()
-		logger = logger.WithFields(log.Fields{
-			"name":      n,
-			"namespace": ns,
-			"gvk":       gvk,
-		})
+	go func() {
+		lease, err := reservation.Acquire(ctx)
+		if err != nil {
+			logger.Infof("Deployment cancelled while queued: %s", err)
+			deployStatus <- pb.NewCancelledStatus(*req)
+			return
+		}
+		defer lease.Release()
 
-		deployed, err := teamClient.DeployUnstructured(resource)
+		if req.GetDryRun() {
+			logger.Infof("Accepting incoming dry run request")
+			dryRun(logger, req, teamClient, deployPhases, deployStatus)
+			return
+		}
+
+		logger.Infof("Accepting incoming deployment request")
+		deploy(ctx, logger, req, cfg, teamClient, lease, resources, deployPhases, inventory, deployStatus)
+	}()
+}
+
+// Apply the resources of a deployment in phases, and wait for them to be rolled out.
+// The worker of the lease is only held while the deployment makes changes to the cluster.
+// Returns when the final status of the deployment has been reported.
+func deploy(ctx context.Context, logger *log.Entry, req *pb.DeploymentRequest, cfg config.Config, teamClient kubeclient.TeamClient, lease *Lease, resources []unstructured.Unstructured, deployPhases [][]indexedResource, inventory string, deployStatus chan *pb.DeploymentStatus) {
+	var err error
+	team := req.GetPayloadSpec().GetTeam()
+
+	var previousInventory []kubeclient.InventoryEntry
+	if len(inventory) > 0 {
+		previousInventory, err = teamClient.Inventory(team, inventory)
 		if err != nil {
-			err = fmt.Errorf("resource %d: %s", index+1, err)
-			logger.Error(err)
-			errors <- err
-			break
+			deployStatus <- pb.NewErrorStatus(*req, err)
+			return
 		}
+	}
 
-		metrics.KubernetesResources.Inc()
+	wait := sync.WaitGroup{}
+	// A resource may fail both while its rollout is monitored, and while it is applied or established.
+	errors := make(chan error, len(resources)*2)
+	rollbackOnFailure := rollbackEnabled(req, cfg)
+	snapshots := make([]snapshot, 0, len(resources))
+	rolloutProgress := newProgress(req, deployStatus)
+	timeout := rolloutTimeout(req)
+	deployResults := newResults(resources)
+
+	if cfg.PreflightPermissions {
+		permissions := requiredPermissions(resources, cfg.ServerSideApply, rollbackOnFailure, nil)
+
+		// Saving the inventory is best effort, unless it is needed for pruning.
+		if req.GetPrune() {
+			pruned := pruneCandidates(previousInventory, resourceInventory(resources))
+			permissions = append(permissions, requiredPermissions(nil, false, false, pruned)...)
+			permissions = append(permissions, kubeclient.InventoryPermissions(team, inventory)...)
+		}
 
-		logger.Infof("Resource %d: successfully deployed %s", index+1, deployed.GetSelfLink())
+		err = checkPermissions(teamClient, permissions)
+		if err != nil {
+			deployStatus <- deployResults.attach(pb.NewFailureStatus(*req, err))
+			return
+		}
+	}
+
+PHASES:
+	for _, phase := range deployPhases {
+		deployed := make([]indexedResource, 0, len(phase))
+
+		for _, r := range phase {
+			if ctx.Err() != nil {
+				break PHASES
+			}
+
+			resource := r.resource
+			addCorrelationID(&resource, req.GetDeliveryID())
+
+			gvk := resource.GroupVersionKind().String()
+			ns := resource.GetNamespace()
+			n := resource.GetName()
+			logger = logger.WithFields(log.Fields{
+				"name":      n,
+				"namespace": ns,
+				"gvk":       gvk,
+			})
+
+			deployResults.start(r.index)
+
+			var live *unstructured.Unstructured
+			if rollbackOnFailure {
+				live, err = teamClient.LiveResource(resource)
+				if err != nil {
+					deployResults.failed(r.index, fmt.Errorf("snapshot previous version: %s", err))
+					err = fmt.Errorf("resource %d: snapshot previous version: %s", r.index+1, err)
+					logger.Error(err)
+					errors <- err
+					break PHASES
+				}
+			}
+
+			result, _, err := teamClient.DeployUnstructured(resource, false)
+			if err != nil {
+				deployResults.failed(r.index, err)
+				err = fmt.Errorf("resource %d: %s", r.index+1, err)
+				logger.Error(err)
+				errors <- err
+				break PHASES
+			}
+
+			metrics.KubernetesResources.Inc()
+			deployResults.applied(r.index)
+
+			logger.Infof("Resource %d: successfully deployed %s", r.index+1, result.GetSelfLink())
+
+			deployed = append(deployed, indexedResource{index: r.index, resource: resource})
+			snapshots = append(snapshots, snapshot{index: r.index, resource: resource, live: live})
 
-		go func(logger *log.Entry, resource unstructured.Unstructured) {
 			wait.Add(1)
-			logger.Infof("Monitoring rollout status of '%s/%s' in namespace '%s' for %s", gvk, n, ns, deploymentTimeout.String())
-			err := teamClient.WaitForDeployment(logger, resource, time.Now().Add(deploymentTimeout))
+			go func(logger *log.Entry, index int, resource unstructured.Unstructured) {
+				logger.Infof("Monitoring rollout status of '%s/%s' in namespace '%s' for %s", gvk, n, ns, timeout.String())
+				watchCtx := strategy.WithProgress(ctx, rolloutProgress.resource(resource))
+				err := teamClient.WaitForDeployment(watchCtx, logger, resource, time.Now().Add(timeout))
+				if err != nil {
+					deployResults.failed(index, err)
+					logger.Error(err)
+					errors <- err
+				} else {
+					deployResults.healthy(index)
+				}
+				logger.Infof("Finished monitoring rollout status of '%s/%s' in namespace '%s'", gvk, n, ns)
+				wait.Done()
+			}(logger, r.index, resource)
+		}
+
+		// Resources in later phases may depend on this phase, so make sure it is usable before continuing.
+		for _, r := range deployed {
+			err := teamClient.WaitForEstablished(ctx, logger, r.resource, time.Now().Add(establishTimeout))
 			if err != nil {
+				deployResults.failed(r.index, err)
+				err = fmt.Errorf("resource %d: %s", r.index+1, err)
 				logger.Error(err)
 				errors <- err
+				break PHASES
 			}
-			logger.Infof("Finished monitoring rollout status of '%s/%s' in namespace '%s'", gvk, n, ns)
-			wait.Done()
-		}(logger, resource)
+		}
 	}
 
-	deployStatus <- pb.NewInProgressStatus(*req)
+	appliedInventory := make([]kubeclient.InventoryEntry, len(snapshots))
+	for i := range snapshots {
+		appliedInventory[i] = kubeclient.NewInventoryEntry(snapshots[i].resource)
+	}
 
-	go func() {
-		logger.Infof("Waiting for resources to be successfully rolled out")
-		wait.Wait()
-		logger.Infof("Finished monitoring all resources")
+	if len(inventory) > 0 {
+		// Resources from the previous deployment are kept in the inventory until they are pruned.
+		err = teamClient.SaveInventory(team, inventory, mergeInventory(previousInventory, appliedInventory))
+		if err != nil {
+			logger.Errorf("Inventory '%s' is not updated: %s", inventory, err)
+		}
+	}
 
-		errCount := len(errors)
-		if errCount == 0 {
-			deployStatus <- pb.NewSuccessStatus(*req)
-		} else {
-			err := <-errors
-			deployStatus <- pb.NewFailureStatus(*req, fmt.Errorf("%s (total of %d errors)", err, errCount))
+	deployStatus <- deployResults.attach(pb.NewInProgressStatus(*req))
+
+	lease.ReleaseWorker()
+
+	logger.Infof("Waiting for resources to be successfully rolled out")
+	wait.Wait()
+	logger.Infof("Finished monitoring all resources")
+
+	errCount := len(errors)
+	pruning := errCount == 0 && req.GetPrune()
+	rollingBack := errCount > 0 && rollbackOnFailure && len(snapshots) > 0
+
+	// Pruning and rolling back make changes to the cluster again.
+	if pruning || rollingBack {
+		err = lease.AcquireWorker(ctx)
+		if err != nil {
+			logger.Infof("Deployment cancelled while waiting for a worker: %s", err)
 		}
-	}()
+	}
+
+	if ctx.Err() != nil {
+		deployStatus <- deployResults.attach(pb.NewCancelledStatus(*req))
+		return
+	}
+
+	if pruning {
+		pruned, err := prune(logger, teamClient, inventory, pruneCandidates(previousInventory, appliedInventory))
+		if err != nil {
+			deployStatus <- deployResults.attach(pb.NewFailureStatus(*req, fmt.Errorf("pruning resources: %s", err)))
+			return
+		}
+		err = teamClient.SaveInventory(team, inventory, appliedInventory)
+		if err != nil {
+			deployStatus <- deployResults.attach(pb.NewFailureStatus(*req, err))
+			return
+		}
+		deployStatus <- deployResults.attach(pb.NewPrunedStatus(*req, pruned))
+		return
+	} else if errCount == 0 {
+		deployStatus <- deployResults.attach(pb.NewSuccessStatus(*req))
+		return
+	}
+
+	err = <-errors
+	err = fmt.Errorf("%s (total of %d errors)", err, errCount)
+
+	if !rollingBack {
+		deployStatus <- deployResults.attach(pb.NewFailureStatus(*req, err))
+		return
+	}
+
+	logger.Infof("Rolling back %d resources to their previous version", len(snapshots))
+	deployStatus <- deployResults.attach(pb.NewRollingBackStatus(*req, err))
+
+	rollbackErr := rollback(ctx, logger, req, teamClient, snapshots)
+	if rollbackErr != nil {
+		logger.Errorf("Rollback failed: %s", rollbackErr)
+	} else {
+		metrics.DeployRolledBack.Inc()
+	}
+
+	deployStatus <- deployResults.attach(pb.NewRollbackStatus(*req, err, rollbackErr))
 }
@@ -0,0 +1,109 @@
+package deployd
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Resources are deployed in phases, so that the resources others depend on are in place first.
+type phase int
+
+const (
+	phaseNamespaces phase = iota
+	phaseDefinitions
+	phaseRBAC
+	phaseConfig
+	phaseWorkloads
+	phaseOther
+	phaseCount
+)
+
+const (
+	// Overrides the phase a resource is deployed in. Valid values are the keys of phaseNames.
+	PhaseAnnotation = "nais.io/deployPhase"
+)
+
+var (
+	establishTimeout = time.Minute * 2
+
+	phaseNames = map[string]phase{
+		"namespaces":  phaseNamespaces,
+		"definitions": phaseDefinitions,
+		"rbac":        phaseRBAC,
+		"config":      phaseConfig,
+		"workloads":   phaseWorkloads,
+		"other":       phaseOther,
+	}
+
+	kindPhases = map[schema.GroupKind]phase{
+		{Group: "", Kind: "Namespace"}:                                    phaseNamespaces,
+		{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: phaseDefinitions,
+		{Group: "", Kind: "ServiceAccount"}:                               phaseRBAC,
+		{Group: "rbac.authorization.k8s.io", Kind: "Role"}:                phaseRBAC,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:         phaseRBAC,
+		{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:         phaseRBAC,
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:  phaseRBAC,
+		{Group: "", Kind: "ConfigMap"}:                                    phaseConfig,
+		{Group: "", Kind: "Secret"}:                                       phaseConfig,
+		{Group: "", Kind: "PersistentVolumeClaim"}:                        phaseConfig,
+		{Group: "", Kind: "Pod"}:                                          phaseWorkloads,
+		{Group: "apps", Kind: "Deployment"}:                               phaseWorkloads,
+		{Group: "extensions", Kind: "Deployment"}:                         phaseWorkloads,
+		{Group: "apps", Kind: "StatefulSet"}:                              phaseWorkloads,
+		{Group: "apps", Kind: "DaemonSet"}:                                phaseWorkloads,
+		{Group: "apps", Kind: "ReplicaSet"}:                               phaseWorkloads,
+		{Group: "batch", Kind: "Job"}:                                     phaseWorkloads,
+		{Group: "batch", Kind: "CronJob"}:                                 phaseWorkloads,
+		{Group: "nais.io", Kind: "Application"}:                           phaseWorkloads,
+		{Group: "nais.io", Kind: "Naisjob"}:                               phaseWorkloads,
+	}
+)
+
+// A resource along with its position in the deployment request, used to identify it towards the user.
+type indexedResource struct {
+	index    int
+	resource unstructured.Unstructured
+}
+
+// Decide which phase a resource is deployed in, either from its phase annotation or from its kind.
+func resourcePhase(resource unstructured.Unstructured) (phase, error) {
+	if name, ok := resource.GetAnnotations()[PhaseAnnotation]; ok {
+		p, ok := phaseNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown deploy phase '%s' in annotation %s", name, PhaseAnnotation)
+		}
+		return p, nil
+	}
+
+	if p, ok := kindPhases[resource.GroupVersionKind().GroupKind()]; ok {
+		return p, nil
+	}
+
+	return phaseOther, nil
+}
+
+// Sort resources into deploy phases. Resources keep their relative order within a phase,
+// and phases without any resources are left out.
+func phases(resources []unstructured.Unstructured) ([][]indexedResource, error) {
+	sorted := make([][]indexedResource, phaseCount)
+
+	for index, resource := range resources {
+		p, err := resourcePhase(resource)
+		if err != nil {
+			return nil, fmt.Errorf("resource %d: %s", index+1, err)
+		}
+		sorted[p] = append(sorted[p], indexedResource{index: index, resource: resource})
+	}
+
+	nonEmpty := make([][]indexedResource, 0, len(sorted))
+	for _, phase := range sorted {
+		if len(phase) > 0 {
+			nonEmpty = append(nonEmpty, phase)
+		}
+	}
+
+	return nonEmpty, nil
+}
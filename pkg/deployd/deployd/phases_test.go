@@ -0,0 +1,52 @@
+package deployd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func resource(apiVersion, kind, name string, annotations map[string]string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetName(name)
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func TestPhases(t *testing.T) {
+	resources := []unstructured.Unstructured{
+		resource("nais.io/v1alpha1", "Application", "app", nil),
+		resource("v1", "Service", "svc", nil),
+		resource("v1", "ConfigMap", "config", nil),
+		resource("apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "crd", nil),
+		resource("v1", "Secret", "secret", nil),
+		resource("example.com/v1", "Custom", "custom", map[string]string{PhaseAnnotation: "config"}),
+		resource("v1", "Namespace", "ns", nil),
+		resource("rbac.authorization.k8s.io/v1", "RoleBinding", "binding", nil),
+	}
+
+	sorted, err := phases(resources)
+	assert.NoError(t, err)
+
+	order := make([][]int, len(sorted))
+	for i, phase := range sorted {
+		for _, r := range phase {
+			order[i] = append(order[i], r.index)
+		}
+	}
+
+	assert.Equal(t, [][]int{{6}, {3}, {7}, {2, 4, 5}, {0}, {1}}, order)
+}
+
+func TestPhasesUnknownAnnotation(t *testing.T) {
+	resources := []unstructured.Unstructured{
+		resource("v1", "ConfigMap", "config", nil),
+		resource("v1", "ConfigMap", "config", map[string]string{PhaseAnnotation: "first"}),
+	}
+
+	_, err := phases(resources)
+	assert.EqualError(t, err, "resource 2: unknown deploy phase 'first' in annotation nais.io/deployPhase")
+}
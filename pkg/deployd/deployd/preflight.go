@@ -0,0 +1,78 @@
+package deployd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/deployd/strategy"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Restoring a snapshot overwrites or deletes the resource, after reading its previous version.
+var rollbackVerbs = []string{"get", "create", "update", "delete"}
+
+// List every action a deployment may perform on its resources: applying them, rolling them back
+// if enabled, and checking the inventory label of pruned resources before deleting them.
+func requiredPermissions(resources []unstructured.Unstructured, serverSideApply, rollback bool, pruned []kubeclient.InventoryEntry) []kubeclient.Permission {
+	permissions := make([]kubeclient.Permission, 0)
+
+	for _, resource := range resources {
+		entry := kubeclient.NewInventoryEntry(resource)
+		verbs := strategy.DeployVerbs(resource.GroupVersionKind(), serverSideApply)
+		if rollback {
+			verbs = append(verbs, rollbackVerbs...)
+		}
+
+		seen := make(map[string]bool)
+		for _, verb := range verbs {
+			if seen[verb] {
+				continue
+			}
+			seen[verb] = true
+			permissions = append(permissions, kubeclient.Permission{Verb: verb, Resource: entry})
+		}
+	}
+
+	for _, entry := range pruned {
+		resource := entry.Unstructured()
+		if unprunable[resource.GroupVersionKind().GroupKind()] {
+			continue
+		}
+		permissions = append(permissions,
+			kubeclient.Permission{Verb: "get", Resource: entry},
+			kubeclient.Permission{Verb: "delete", Resource: entry},
+		)
+	}
+
+	return permissions
+}
+
+// Check that the team is allowed to perform every action, so that a deployment is not
+// left half applied because of a missing permission. Returns an error listing all missing permissions.
+func checkPermissions(teamClient kubeclient.TeamClient, permissions []kubeclient.Permission) error {
+	missing, err := teamClient.MissingPermissions(permissions)
+	if err != nil {
+		return fmt.Errorf("checking permissions: %s", err)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(missing))
+	for i := range missing {
+		names[i] = missing[i].String()
+	}
+
+	return fmt.Errorf("deployment rejected, as the team is not allowed to: %s", strings.Join(names, "; "))
+}
+
+// The inventory a deployment will have once all its resources are applied.
+func resourceInventory(resources []unstructured.Unstructured) []kubeclient.InventoryEntry {
+	entries := make([]kubeclient.InventoryEntry, len(resources))
+	for i := range resources {
+		entries[i] = kubeclient.NewInventoryEntry(resources[i])
+	}
+	return entries
+}
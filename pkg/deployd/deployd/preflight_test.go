@@ -0,0 +1,93 @@
+package deployd
+
+import (
+	"testing"
+
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Team client denying every action on resources with the given names.
+type deniedTeamClient struct {
+	kubeclient.TeamClient
+	denied map[string]bool
+}
+
+func (c *deniedTeamClient) MissingPermissions(permissions []kubeclient.Permission) ([]kubeclient.Permission, error) {
+	missing := make([]kubeclient.Permission, 0)
+	for _, permission := range permissions {
+		if c.denied[permission.Resource.Name] {
+			missing = append(missing, permission)
+		}
+	}
+	return missing, nil
+}
+
+func permissionNames(permissions []kubeclient.Permission) []string {
+	names := make([]string, len(permissions))
+	for i := range permissions {
+		names[i] = permissions[i].String()
+	}
+	return names
+}
+
+func TestRequiredPermissions(t *testing.T) {
+	resources := []unstructured.Unstructured{
+		resource("v1", "ConfigMap", "config", nil),
+		resource("batch/v1", "Job", "job", nil),
+	}
+
+	t.Run("create or update", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"create v1/ConfigMap/config",
+			"get v1/ConfigMap/config",
+			"update v1/ConfigMap/config",
+			"delete batch/v1/Job/job",
+			"create batch/v1/Job/job",
+		}, permissionNames(requiredPermissions(resources, false, false, nil)))
+	})
+
+	t.Run("server-side apply", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"create v1/ConfigMap/config",
+			"get v1/ConfigMap/config",
+			"patch v1/ConfigMap/config",
+			"delete batch/v1/Job/job",
+			"create batch/v1/Job/job",
+		}, permissionNames(requiredPermissions(resources, true, false, nil)))
+	})
+
+	t.Run("rollback and pruning", func(t *testing.T) {
+		pruned := []kubeclient.InventoryEntry{
+			{Version: "v1", Kind: "Service", Namespace: "aura", Name: "old"},
+			{Version: "v1", Kind: "Namespace", Name: "aura"},
+		}
+		assert.Equal(t, []string{
+			"create v1/ConfigMap/config",
+			"get v1/ConfigMap/config",
+			"update v1/ConfigMap/config",
+			"delete v1/ConfigMap/config",
+			"delete batch/v1/Job/job",
+			"create batch/v1/Job/job",
+			"get batch/v1/Job/job",
+			"update batch/v1/Job/job",
+			"get v1/Service/aura/old",
+			"delete v1/Service/aura/old",
+		}, permissionNames(requiredPermissions(resources, false, true, pruned)))
+	})
+}
+
+func TestCheckPermissions(t *testing.T) {
+	resources := []unstructured.Unstructured{
+		resource("v1", "ConfigMap", "allowed", nil),
+		resource("v1", "ConfigMap", "denied", nil),
+	}
+	permissions := requiredPermissions(resources, true, false, nil)
+
+	err := checkPermissions(&deniedTeamClient{}, permissions)
+	assert.NoError(t, err)
+
+	err = checkPermissions(&deniedTeamClient{denied: map[string]bool{"denied": true}}, permissions)
+	assert.EqualError(t, err, "deployment rejected, as the team is not allowed to: create v1/ConfigMap/denied; get v1/ConfigMap/denied; patch v1/ConfigMap/denied")
+}
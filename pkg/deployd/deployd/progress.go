@@ -0,0 +1,56 @@
+package deployd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/strategy"
+	"github.com/navikt/deployment/pkg/pb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// At most one progress status is sent per deployment within this interval.
+	progressInterval = time.Second * 15
+)
+
+// Forwards rollout progress from the watch strategies as intermediate deployment statuses.
+// Repeated messages are suppressed, and statuses are throttled, so that long rollouts
+// are visible to the deployer without flooding hookd.
+type progress struct {
+	lock     sync.Mutex
+	req      *pb.DeploymentRequest
+	statuses chan *pb.DeploymentStatus
+	sent     time.Time
+	messages map[string]string
+}
+
+func newProgress(req *pb.DeploymentRequest, statuses chan *pb.DeploymentStatus) *progress {
+	return &progress{
+		req:      req,
+		statuses: statuses,
+		messages: make(map[string]string),
+	}
+}
+
+// Returns a function receiving the progress of a single resource.
+func (p *progress) resource(resource unstructured.Unstructured) strategy.ProgressFunc {
+	name := fmt.Sprintf("%s/%s", resource.GetKind(), resource.GetName())
+	return func(message string) {
+		p.report(name, message)
+	}
+}
+
+func (p *progress) report(name, message string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.messages[name] == message || time.Since(p.sent) < progressInterval {
+		return
+	}
+
+	p.messages[name] = message
+	p.sent = time.Now()
+	p.statuses <- pb.NewProgressStatus(*p.req, fmt.Sprintf("%s: %s", name, message))
+}
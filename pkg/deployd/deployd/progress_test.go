@@ -0,0 +1,33 @@
+package deployd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	statuses := make(chan *pb.DeploymentStatus, 10)
+	p := newProgress(&pb.DeploymentRequest{DeliveryID: "123"}, statuses)
+	report := p.resource(resource("apps/v1", "Deployment", "app", nil))
+
+	report("1/2 replicas updated, 0 available")
+	report("1/2 replicas updated, 1 available")
+
+	assert.Len(t, statuses, 1)
+	status := <-statuses
+	assert.True(t, status.GetProgress())
+	assert.Equal(t, pb.GithubDeploymentState_in_progress, status.GetState())
+	assert.Equal(t, "Deployment/app: 1/2 replicas updated, 0 available", status.GetDescription())
+
+	defer func(interval time.Duration) { progressInterval = interval }(progressInterval)
+	progressInterval = 0
+
+	report("1/2 replicas updated, 0 available")
+	report("2/2 replicas updated, 1 available")
+
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "Deployment/app: 2/2 replicas updated, 1 available", (<-statuses).GetDescription())
+}
@@ -0,0 +1,145 @@
+package deployd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	invalidInventoryCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+	// Deleting these would take every resource inside them along, so they are never pruned.
+	unprunable = map[schema.GroupKind]bool{
+		{Group: "", Kind: "Namespace"}:                                    true,
+		{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: true,
+	}
+)
+
+// The inventory groups all resources deployed from the same source. Unless the deployer names it explicitly,
+// it is named after the repository the deployment originates from. Returns an empty string if neither is known.
+func inventoryID(req *pb.DeploymentRequest) (string, error) {
+	id := req.GetInventory()
+
+	if len(id) == 0 {
+		repo := req.GetDeployment().GetRepository()
+		if len(repo.GetOwner()) == 0 || len(repo.GetName()) == 0 {
+			return "", nil
+		}
+		id = invalidInventoryCharacters.ReplaceAllString(strings.ToLower(repo.GetOwner()+"-"+repo.GetName()), "-")
+		if len(id) > validation.LabelValueMaxLength {
+			id = id[:validation.LabelValueMaxLength]
+		}
+		id = strings.Trim(id, "-")
+		if len(id) == 0 {
+			return "", nil
+		}
+	}
+
+	if invalidInventoryCharacters.MatchString(id) {
+		return "", fmt.Errorf("inventory '%s' may only contain lowercase letters, digits and '-'", id)
+	}
+
+	errs := validation.IsValidLabelValue(id)
+	if len(errs) > 0 {
+		return "", fmt.Errorf("inventory '%s': %s", id, strings.Join(errs, "; "))
+	}
+
+	return id, nil
+}
+
+// Label a resource with the inventory it belongs to.
+func addInventoryLabel(resource *unstructured.Unstructured, id string) {
+	labels := resource.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[kubeclient.InventoryLabel] = id
+	resource.SetLabels(labels)
+}
+
+func inventoryContains(entries []kubeclient.InventoryEntry, entry kubeclient.InventoryEntry) bool {
+	for _, e := range entries {
+		if e.SameResource(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Combine two inventories, so that resources from an incomplete deployment are not forgotten.
+func mergeInventory(previous, current []kubeclient.InventoryEntry) []kubeclient.InventoryEntry {
+	merged := append([]kubeclient.InventoryEntry{}, current...)
+	for _, entry := range previous {
+		if !inventoryContains(merged, entry) {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// Return the resources from the previous inventory that are no longer part of the deployment.
+func pruneCandidates(previous, current []kubeclient.InventoryEntry) []kubeclient.InventoryEntry {
+	candidates := make([]kubeclient.InventoryEntry, 0)
+	for _, entry := range previous {
+		if !inventoryContains(current, entry) {
+			candidates = append(candidates, entry)
+		}
+	}
+	return candidates
+}
+
+// Delete resources in the reverse order of deploy phases, so that workloads are gone before their configuration.
+// Resources that no longer carry the label of the inventory have been taken over by another deployment, and are left alone.
+// Returns the names of the deleted resources.
+func prune(logger *log.Entry, teamClient kubeclient.TeamClient, inventory string, candidates []kubeclient.InventoryEntry) ([]string, error) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, _ := resourcePhase(candidates[i].Unstructured())
+		b, _ := resourcePhase(candidates[j].Unstructured())
+		return a > b
+	})
+
+	pruned := make([]string, 0, len(candidates))
+
+	for _, entry := range candidates {
+		resource := entry.Unstructured()
+
+		if unprunable[resource.GroupVersionKind().GroupKind()] {
+			logger.Warnf("Not pruning %s, as it may contain other resources", entry)
+			continue
+		}
+
+		live, err := teamClient.LiveResource(resource)
+		if err != nil {
+			return pruned, fmt.Errorf("%s: %s", entry, err)
+		}
+
+		if live == nil {
+			logger.Infof("Not pruning %s, as it no longer exists", entry)
+			continue
+		}
+
+		if live.GetLabels()[kubeclient.InventoryLabel] != inventory {
+			logger.Warnf("Not pruning %s, as it no longer belongs to inventory '%s'", entry, inventory)
+			continue
+		}
+
+		err = teamClient.DeleteUnstructured(resource)
+		if err != nil {
+			return pruned, fmt.Errorf("%s: %s", entry, err)
+		}
+
+		logger.Infof("Pruned %s, as it is no longer part of the deployment", entry)
+		pruned = append(pruned, entry.String())
+	}
+
+	return pruned, nil
+}
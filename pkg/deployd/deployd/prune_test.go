@@ -0,0 +1,215 @@
+package deployd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/config"
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Team client serving live resources from memory, and recording deleted resources.
+type pruneTeamClient struct {
+	fakeTeamClient
+	deleted []string
+}
+
+func (c *pruneTeamClient) DeleteUnstructured(resource unstructured.Unstructured) error {
+	c.deleted = append(c.deleted, resource.GetName())
+	return nil
+}
+
+// Team client keeping deployed resources and inventories in memory.
+type clusterTeamClient struct {
+	fakeTeamClient
+	inventories map[string][]kubeclient.InventoryEntry
+}
+
+func (c *clusterTeamClient) DeployUnstructured(resource unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, string, error) {
+	c.live[resource.GetName()] = resource.DeepCopy()
+	return &resource, "", nil
+}
+
+func (c *clusterTeamClient) DeleteUnstructured(resource unstructured.Unstructured) error {
+	delete(c.live, resource.GetName())
+	return nil
+}
+
+func (c *clusterTeamClient) Inventory(namespace, id string) ([]kubeclient.InventoryEntry, error) {
+	return c.inventories[id], nil
+}
+
+func (c *clusterTeamClient) SaveInventory(namespace, id string, entries []kubeclient.InventoryEntry) error {
+	c.inventories[id] = entries
+	return nil
+}
+
+func (c *clusterTeamClient) WaitForEstablished(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	return nil
+}
+
+func labelled(name, inventory string) *unstructured.Unstructured {
+	u := resource("v1", "ConfigMap", name, nil)
+	if len(inventory) > 0 {
+		addInventoryLabel(&u, inventory)
+	}
+	return &u
+}
+
+func TestInventoryID(t *testing.T) {
+	request := func(inventory, owner, name string) *pb.DeploymentRequest {
+		return &pb.DeploymentRequest{
+			Inventory: inventory,
+			Deployment: &pb.DeploymentSpec{
+				Repository: &pb.GithubRepository{Owner: owner, Name: name},
+			},
+		}
+	}
+
+	tests := []struct {
+		req *pb.DeploymentRequest
+		id  string
+		err string
+	}{
+		{req: request("my-app", "navikt", "deployment"), id: "my-app"},
+		{req: request("", "navikt", "My_Repo.git"), id: "navikt-my-repo-git"},
+		{req: request("", "", ""), id: ""},
+		{req: &pb.DeploymentRequest{}, id: ""},
+		{req: request("My_App", "navikt", "deployment"), err: "inventory 'My_App' may only contain lowercase letters, digits and '-'"},
+	}
+
+	for _, test := range tests {
+		id, err := inventoryID(test.req)
+		if len(test.err) > 0 {
+			assert.EqualError(t, err, test.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, test.id, id)
+		}
+	}
+}
+
+func TestPruneCandidates(t *testing.T) {
+	previous := []kubeclient.InventoryEntry{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "aura", Name: "config"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "aura", Name: "app"},
+		{Version: "v1", Kind: "Secret", Namespace: "aura", Name: "secret"},
+	}
+	current := []kubeclient.InventoryEntry{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "aura", Name: "config"},
+		{Group: "apps", Version: "v1beta1", Kind: "Deployment", Namespace: "aura", Name: "app"},
+	}
+
+	candidates := pruneCandidates(previous, current)
+	assert.Equal(t, []kubeclient.InventoryEntry{previous[2]}, candidates)
+
+	merged := mergeInventory(previous, current)
+	assert.Equal(t, append(current, previous[2]), merged)
+}
+
+func TestPrune(t *testing.T) {
+	teamClient := &pruneTeamClient{
+		fakeTeamClient: fakeTeamClient{
+			live: map[string]*unstructured.Unstructured{
+				"owned":     labelled("owned", "my-app"),
+				"moved":     labelled("moved", "other-app"),
+				"unlabeled": labelled("unlabeled", ""),
+			},
+		},
+	}
+
+	candidates := []kubeclient.InventoryEntry{
+		{Version: "v1", Kind: "ConfigMap", Name: "owned"},
+		{Version: "v1", Kind: "ConfigMap", Name: "moved"},
+		{Version: "v1", Kind: "ConfigMap", Name: "unlabeled"},
+		{Version: "v1", Kind: "ConfigMap", Name: "gone"},
+	}
+
+	pruned, err := prune(log.NewEntry(log.New()), teamClient, "my-app", candidates)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"owned"}, teamClient.deleted)
+	assert.Equal(t, []string{"v1/ConfigMap/owned"}, pruned)
+}
+
+func pruneRequest(t *testing.T, inventory string, names ...string) *pb.DeploymentRequest {
+	resources := make([]json.RawMessage, len(names))
+	for i, name := range names {
+		resources[i] = json.RawMessage(fmt.Sprintf(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "%s"}}`, name))
+	}
+	data, err := json.Marshal(resources)
+	assert.NoError(t, err)
+	kube, err := pb.KubernetesFromJSONResources(data)
+	assert.NoError(t, err)
+
+	return &pb.DeploymentRequest{
+		DeliveryID:  inventory,
+		Cluster:     "local",
+		Time:        pb.TimeAsTimestamp(time.Now()),
+		Deadline:    time.Now().Add(time.Minute).Unix(),
+		Inventory:   inventory,
+		Prune:       true,
+		PayloadSpec: &pb.Payload{Team: "aura", Kubernetes: kube},
+		Deployment: &pb.DeploymentSpec{
+			Repository: &pb.GithubRepository{Owner: "navikt", Name: "monorepo"},
+		},
+	}
+}
+
+func runDeployment(t *testing.T, teamClient kubeclient.TeamClient, req *pb.DeploymentRequest) *pb.DeploymentStatus {
+	statuses := make(chan *pb.DeploymentStatus, 10)
+	Run(context.Background(), log.NewEntry(log.New()), req, config.Config{Cluster: "local"}, &fakeTeamClientProvider{teamClient}, NewScheduler(1), statuses)
+
+	for {
+		select {
+		case status := <-statuses:
+			if status.GetState().Finished() {
+				return status
+			}
+		case <-time.After(time.Second):
+			t.Fatal("deployment did not finish")
+			return nil
+		}
+	}
+}
+
+func TestPruneRequiresExplicitInventory(t *testing.T) {
+	teamClient := &clusterTeamClient{
+		fakeTeamClient: fakeTeamClient{live: make(map[string]*unstructured.Unstructured)},
+		inventories:    make(map[string][]kubeclient.InventoryEntry),
+	}
+
+	status := runDeployment(t, teamClient, pruneRequest(t, "", "config"))
+	assert.Equal(t, pb.GithubDeploymentState_error, status.GetState())
+	assert.Contains(t, status.GetDescription(), "pruning requires an explicit inventory")
+	assert.Empty(t, teamClient.live)
+}
+
+func TestPruneInventoriesFromSameRepository(t *testing.T) {
+	teamClient := &clusterTeamClient{
+		fakeTeamClient: fakeTeamClient{live: make(map[string]*unstructured.Unstructured)},
+		inventories:    make(map[string][]kubeclient.InventoryEntry),
+	}
+
+	for _, req := range []*pb.DeploymentRequest{
+		pruneRequest(t, "app-a", "a-config", "a-old"),
+		pruneRequest(t, "app-b", "b-config"),
+		pruneRequest(t, "app-a", "a-config"),
+	} {
+		status := runDeployment(t, teamClient, req)
+		assert.Equal(t, pb.GithubDeploymentState_success, status.GetState(), status.GetDescription())
+	}
+
+	assert.Contains(t, teamClient.live, "a-config")
+	assert.Contains(t, teamClient.live, "b-config")
+	assert.NotContains(t, teamClient.live, "a-old")
+	assert.Equal(t, "app-b", teamClient.live["b-config"].GetLabels()[kubeclient.InventoryLabel])
+	assert.Len(t, teamClient.inventories["app-a"], 1)
+	assert.Len(t, teamClient.inventories["app-b"], 1)
+}
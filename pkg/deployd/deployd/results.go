@@ -0,0 +1,80 @@
+package deployd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/navikt/deployment/pkg/pb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Collects the outcome of every resource in a deployment, so that statuses can tell exactly
+// which resources failed and why. Resources are identified by their index in the deployment request.
+type results struct {
+	lock      sync.Mutex
+	resources []*pb.ResourceStatus
+	started   []time.Time
+}
+
+func newResults(resources []unstructured.Unstructured) *results {
+	r := &results{
+		resources: make([]*pb.ResourceStatus, len(resources)),
+		started:   make([]time.Time, len(resources)),
+	}
+	for i, resource := range resources {
+		gvk := resource.GroupVersionKind()
+		r.resources[i] = &pb.ResourceStatus{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: resource.GetNamespace(),
+			Name:      resource.GetName(),
+			State:     pb.ResourceState_unapplied,
+		}
+	}
+	return r
+}
+
+// Record that a resource is about to be applied. Durations are measured from this point.
+func (r *results) start(index int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.started[index] = time.Now()
+}
+
+func (r *results) applied(index int) {
+	r.set(index, pb.ResourceState_applied, "")
+}
+
+func (r *results) healthy(index int) {
+	r.set(index, pb.ResourceState_healthy, "")
+}
+
+func (r *results) failed(index int, err error) {
+	r.set(index, pb.ResourceState_failed, err.Error())
+}
+
+func (r *results) set(index int, state pb.ResourceState, message string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	resource := r.resources[index]
+	resource.State = state
+	resource.Message = message
+	if !r.started[index].IsZero() {
+		resource.DurationMillis = time.Since(r.started[index]).Milliseconds()
+	}
+}
+
+// Attach a snapshot of the current results to a deployment status.
+func (r *results) attach(status *pb.DeploymentStatus) *pb.DeploymentStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	status.Resources = make([]*pb.ResourceStatus, len(r.resources))
+	for i, resource := range r.resources {
+		status.Resources[i] = proto.Clone(resource).(*pb.ResourceStatus)
+	}
+	return status
+}
@@ -0,0 +1,46 @@
+package deployd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestResults(t *testing.T) {
+	r := newResults([]unstructured.Unstructured{
+		resource("v1", "ConfigMap", "config", nil),
+		resource("apps/v1", "Deployment", "app", nil),
+		resource("v1", "Service", "app", nil),
+	})
+
+	r.start(0)
+	r.applied(0)
+	r.start(1)
+	r.applied(1)
+	status := r.attach(pb.NewInProgressStatus(pb.DeploymentRequest{}))
+
+	r.healthy(0)
+	r.failed(1, fmt.Errorf("pod app-abc123: CrashLoopBackOff"))
+
+	assert.Len(t, status.GetResources(), 3)
+	assert.Equal(t, pb.ResourceState_applied, status.GetResources()[1].GetState(), "attached results must not change afterwards")
+
+	status = r.attach(pb.NewFailureStatus(pb.DeploymentRequest{}, fmt.Errorf("failed")))
+	resources := status.GetResources()
+
+	assert.Equal(t, "", resources[0].GetGroup())
+	assert.Equal(t, "ConfigMap", resources[0].GetKind())
+	assert.Equal(t, pb.ResourceState_healthy, resources[0].GetState())
+
+	assert.Equal(t, "apps", resources[1].GetGroup())
+	assert.Equal(t, "v1", resources[1].GetVersion())
+	assert.Equal(t, "app", resources[1].GetName())
+	assert.Equal(t, pb.ResourceState_failed, resources[1].GetState())
+	assert.Equal(t, "pod app-abc123: CrashLoopBackOff", resources[1].GetMessage())
+
+	assert.Equal(t, pb.ResourceState_unapplied, resources[2].GetState())
+	assert.Equal(t, int64(0), resources[2].GetDurationMillis())
+}
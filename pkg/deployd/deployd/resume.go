@@ -0,0 +1,135 @@
+package deployd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/navikt/deployment/pkg/deployd/config"
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"github.com/navikt/deployment/pkg/deployd/strategy"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Find the live version of every resource in a deployment, and return the names of any resources
+// that no longer carry the correlation ID of the deployment, i.e. that were never applied, or
+// that have since been changed by another deployment.
+func changedResources(teamClient kubeclient.TeamClient, req *pb.DeploymentRequest, resources []unstructured.Unstructured) ([]string, error) {
+	changed := make([]string, 0)
+	for i, resource := range resources {
+		live, err := teamClient.LiveResource(resource)
+		if err != nil {
+			return nil, fmt.Errorf("resource %d: %s", i+1, err)
+		}
+		if live == nil || live.GetAnnotations()[kubeclient.CorrelationIDAnnotation] != req.GetDeliveryID() {
+			changed = append(changed, fmt.Sprintf("%s/%s", resource.GetKind(), resource.GetName()))
+		}
+	}
+	return changed, nil
+}
+
+// Resume monitoring the rollout of a deployment that was in progress when deployd was last stopped,
+// and report its final status. The deployment has already been applied, so resources are only watched.
+// Like Run, Resume returns as soon as the deployment is queued.
+func Resume(ctx context.Context, logger *log.Entry, req *pb.DeploymentRequest, cfg config.Config, kube kubeclient.TeamClientProvider, scheduler *Scheduler, deployStatus chan *pb.DeploymentStatus) {
+	logger.Infof("Resuming deployment interrupted by restart")
+
+	if err := matchesCluster(*req, cfg.Cluster); err != nil {
+		logger.Warnf("Not resuming deployment: running in %s, but deployment is addressed to %s", cfg.Cluster, req.GetCluster())
+		return
+	}
+
+	teamClient, err := newTeamClient(req, cfg, kube)
+	if err != nil {
+		deployStatus <- pb.NewErrorStatus(*req, err)
+		return
+	}
+
+	resources, err := requestResources(req)
+	if err != nil {
+		deployStatus <- pb.NewErrorStatus(*req, err)
+		return
+	}
+
+	reservation := scheduler.Reserve(resources)
+
+	go func() {
+		lease, err := reservation.Acquire(ctx)
+		if err != nil {
+			deployStatus <- pb.NewCancelledStatus(*req)
+			return
+		}
+		defer lease.Release()
+
+		metrics.DeployResumed.Inc()
+
+		// The resources must be inspected while they are locked, so that they cannot change underneath us.
+		changed, err := changedResources(teamClient, req, resources)
+		if err != nil {
+			deployStatus <- pb.NewErrorStatus(*req, err)
+			return
+		}
+		if len(changed) > 0 {
+			err = fmt.Errorf("deployd restarted during the deployment, and these resources were not deployed or have since been changed: %s", strings.Join(changed, ", "))
+			deployStatus <- pb.NewFailureStatus(*req, err)
+			return
+		}
+
+		// Monitoring a rollout does not change anything, so the worker is not needed.
+		lease.ReleaseWorker()
+
+		watch(ctx, logger, req, teamClient, resources, deployStatus)
+	}()
+}
+
+// Wait for already applied resources to be rolled out, and report the final status of the deployment.
+// The rollout timeout counts from when the deployment was requested.
+func watch(ctx context.Context, logger *log.Entry, req *pb.DeploymentRequest, teamClient kubeclient.TeamClient, resources []unstructured.Unstructured, deployStatus chan *pb.DeploymentStatus) {
+	wait := sync.WaitGroup{}
+	errors := make(chan error, len(resources))
+	deadline := req.Timestamp().Add(rolloutTimeout(req))
+	rolloutProgress := newProgress(req, deployStatus)
+	deployResults := newResults(resources)
+
+	for i := range resources {
+		deployResults.applied(i)
+	}
+
+	deployStatus <- deployResults.attach(pb.NewInProgressStatus(*req))
+
+	for i, resource := range resources {
+		wait.Add(1)
+		go func(index int, resource unstructured.Unstructured) {
+			defer wait.Done()
+			watchCtx := strategy.WithProgress(ctx, rolloutProgress.resource(resource))
+			err := teamClient.WaitForDeployment(watchCtx, logger, resource, deadline)
+			if err != nil {
+				deployResults.failed(index, err)
+				errors <- fmt.Errorf("resource %d: %s", index+1, err)
+				return
+			}
+			deployResults.healthy(index)
+		}(i, resource)
+	}
+
+	wait.Wait()
+	logger.Infof("Finished monitoring all resources")
+
+	if ctx.Err() != nil {
+		deployStatus <- deployResults.attach(pb.NewCancelledStatus(*req))
+		return
+	}
+
+	errCount := len(errors)
+	if errCount == 0 {
+		deployStatus <- deployResults.attach(pb.NewSuccessStatus(*req))
+		return
+	}
+
+	err := <-errors
+	deployStatus <- deployResults.attach(pb.NewFailureStatus(*req, fmt.Errorf("%s (total of %d errors)", err, errCount)))
+}
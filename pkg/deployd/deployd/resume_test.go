@@ -0,0 +1,104 @@
+package deployd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/config"
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Team client serving live resources from memory, with rollouts that always succeed.
+type fakeTeamClient struct {
+	kubeclient.TeamClient
+	live map[string]*unstructured.Unstructured
+}
+
+type fakeTeamClientProvider struct {
+	teamClient kubeclient.TeamClient
+}
+
+func (p *fakeTeamClientProvider) TeamClient(team, namespace string, autoCreateServiceAccount, serverSideApply bool) (kubeclient.TeamClient, error) {
+	return p.teamClient, nil
+}
+
+func (c *fakeTeamClient) LiveResource(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	return c.live[resource.GetName()], nil
+}
+
+func (c *fakeTeamClient) WaitForDeployment(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	return nil
+}
+
+func liveResource(name, correlationID string) *unstructured.Unstructured {
+	u := resource("v1", "ConfigMap", name, map[string]string{
+		kubeclient.CorrelationIDAnnotation: correlationID,
+	})
+	return &u
+}
+
+func resumeRequest(t *testing.T) *pb.DeploymentRequest {
+	kube, err := pb.KubernetesFromJSONResources(json.RawMessage(`[
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "first"}},
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "second"}}
+	]`))
+	assert.NoError(t, err)
+	return &pb.DeploymentRequest{
+		DeliveryID:  "123",
+		Cluster:     "local",
+		Time:        pb.TimeAsTimestamp(time.Now()),
+		PayloadSpec: &pb.Payload{Team: "aura", Kubernetes: kube},
+	}
+}
+
+func resumeStatuses(t *testing.T, teamClient *fakeTeamClient, count int) []*pb.DeploymentStatus {
+	statuses := make(chan *pb.DeploymentStatus, 10)
+	Resume(context.Background(), log.NewEntry(log.New()), resumeRequest(t), config.Config{Cluster: "local"}, &fakeTeamClientProvider{teamClient}, NewScheduler(1), statuses)
+
+	received := make([]*pb.DeploymentStatus, 0, count)
+	for len(received) < count {
+		select {
+		case status := <-statuses:
+			received = append(received, status)
+		case <-time.After(time.Second):
+			t.Fatalf("expected %d statuses, got %d", count, len(received))
+		}
+	}
+	return received
+}
+
+func TestResume(t *testing.T) {
+	teamClient := &fakeTeamClient{
+		live: map[string]*unstructured.Unstructured{
+			"first":  liveResource("first", "123"),
+			"second": liveResource("second", "123"),
+		},
+	}
+
+	statuses := resumeStatuses(t, teamClient, 2)
+
+	assert.Equal(t, pb.GithubDeploymentState_in_progress, statuses[0].GetState())
+	assert.Equal(t, pb.GithubDeploymentState_success, statuses[1].GetState())
+	for _, resource := range statuses[1].GetResources() {
+		assert.Equal(t, pb.ResourceState_healthy, resource.GetState())
+	}
+}
+
+func TestResumeChangedResources(t *testing.T) {
+	teamClient := &fakeTeamClient{
+		live: map[string]*unstructured.Unstructured{
+			"first": liveResource("first", "456"),
+		},
+	}
+
+	statuses := resumeStatuses(t, teamClient, 1)
+
+	assert.Equal(t, pb.GithubDeploymentState_failure, statuses[0].GetState())
+	assert.Contains(t, statuses[0].GetDescription(), "ConfigMap/first, ConfigMap/second")
+}
@@ -0,0 +1,88 @@
+package deployd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/config"
+	"github.com/navikt/deployment/pkg/deployd/kubeclient"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// A deployed resource along with its live version from before the deployment.
+// The live version is nil if the resource did not exist.
+type snapshot struct {
+	index    int
+	resource unstructured.Unstructured
+	live     *unstructured.Unstructured
+}
+
+// Failed deployments are rolled back if requested by the deployer, or if the team has opted in for all its deployments.
+func rollbackEnabled(req *pb.DeploymentRequest, cfg config.Config) bool {
+	if req.GetRollback() {
+		return true
+	}
+	for _, team := range cfg.RollbackTeams {
+		if team == req.GetPayloadSpec().GetTeam() {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore resources to the state they were in before the deployment, in reverse order of deployment,
+// and wait for the restored resources to report healthy status.
+// Resources that did not exist before the deployment are deleted.
+func rollback(ctx context.Context, logger *log.Entry, req *pb.DeploymentRequest, teamClient kubeclient.TeamClient, snapshots []snapshot) error {
+	restored := make([]unstructured.Unstructured, 0, len(snapshots))
+	correlationID := req.GetDeliveryID() + "-rollback"
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		s := snapshots[i]
+
+		var live *unstructured.Unstructured
+		if s.live != nil {
+			live = s.live.DeepCopy()
+			addCorrelationID(live, correlationID)
+		}
+
+		result, err := teamClient.RestoreUnstructured(s.resource, live)
+		if err != nil {
+			return fmt.Errorf("resource %d: %s", s.index+1, err)
+		}
+
+		if result == nil {
+			logger.Infof("Resource %d: deleted, as it did not exist before the deployment", s.index+1)
+			continue
+		}
+
+		logger.Infof("Resource %d: restored previous version of %s", s.index+1, result.GetSelfLink())
+		restored = append(restored, *live)
+	}
+
+	wait := sync.WaitGroup{}
+	errors := make(chan error, len(restored))
+
+	for _, resource := range restored {
+		wait.Add(1)
+		go func(resource unstructured.Unstructured) {
+			defer wait.Done()
+			err := teamClient.WaitForDeployment(ctx, logger, resource, time.Now().Add(rolloutTimeout(req)))
+			if err != nil {
+				errors <- fmt.Errorf("%s/%s: %s", resource.GetKind(), resource.GetName(), err)
+			}
+		}(resource)
+	}
+
+	wait.Wait()
+
+	if len(errors) > 0 {
+		return <-errors
+	}
+
+	return nil
+}
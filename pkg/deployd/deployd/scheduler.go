@@ -0,0 +1,218 @@
+package deployd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Scheduler limits how many deployments are applied and monitored at the same time,
+// and makes sure that two deployments never touch the same object concurrently.
+//
+// A deployment first locks every object it contains, and then waits for a free worker.
+// Deployments holding a worker never wait for locks, so a deployment blocked by another
+// one does not take up a worker while it waits. Workers are only needed to make changes
+// to the cluster, so a deployment gives up its worker while its rollout is monitored,
+// keeping its objects locked.
+//
+// Every object has a queue of deployments waiting for it. A deployment reserves its place in the
+// queues of all its objects at once, so that deployments of the same object are applied in the order
+// they were reserved, and deployments sharing several objects can never wait for each other.
+type Scheduler struct {
+	workers chan struct{}
+	lock    sync.Mutex
+	objects map[objectKey][]*waiter
+}
+
+// Objects are identified by namespace, API group, kind and name.
+type objectKey struct {
+	namespace string
+	group     string
+	kind      string
+	name      string
+}
+
+func (k objectKey) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", k.namespace, k.group, k.kind, k.name)
+}
+
+// A place in the queue of an object. The ready channel is closed when the waiter reaches the front of the queue.
+type waiter struct {
+	ready chan struct{}
+}
+
+// Reservation holds the place of a deployment in the queues of its objects.
+type Reservation struct {
+	scheduler *Scheduler
+	keys      []objectKey
+	waiters   []*waiter
+	queued    time.Time
+}
+
+func NewScheduler(concurrency int) *Scheduler {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Scheduler{
+		workers: make(chan struct{}, concurrency),
+		objects: make(map[objectKey][]*waiter),
+	}
+}
+
+// Sorted and unique object keys of a set of resources.
+func objectKeys(resources []unstructured.Unstructured) []objectKey {
+	seen := make(map[objectKey]bool)
+	keys := make([]objectKey, 0, len(resources))
+	for _, resource := range resources {
+		key := objectKey{
+			namespace: resource.GetNamespace(),
+			group:     resource.GroupVersionKind().Group,
+			kind:      resource.GetKind(),
+			name:      resource.GetName(),
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// Reserve queues a deployment for all its objects, behind any deployments reserved earlier. Reserve never blocks,
+// and must be called in the order deployments are received. Acquire must be called on the returned reservation.
+func (s *Scheduler) Reserve(resources []unstructured.Unstructured) *Reservation {
+	metrics.QueueDepth.Inc()
+
+	keys := objectKeys(resources)
+	r := &Reservation{
+		scheduler: s,
+		keys:      keys,
+		waiters:   make([]*waiter, len(keys)),
+		queued:    time.Now(),
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, key := range keys {
+		w := &waiter{ready: make(chan struct{})}
+		if len(s.objects[key]) == 0 {
+			close(w.ready)
+		}
+		s.objects[key] = append(s.objects[key], w)
+		r.waiters[i] = w
+	}
+
+	return r
+}
+
+// Lease is held by a running deployment. It keeps the objects of the deployment locked until released,
+// and holds a worker whenever the deployment makes changes to the cluster. A lease is not safe for concurrent use.
+type Lease struct {
+	reservation *Reservation
+	working     bool
+}
+
+// Acquire reserves and acquires access to a set of resources in one go. See Reservation.Acquire.
+func (s *Scheduler) Acquire(ctx context.Context, resources []unstructured.Unstructured) (*Lease, error) {
+	return s.Reserve(resources).Acquire(ctx)
+}
+
+// Acquire blocks until all objects are locked and a worker is available, or the context is cancelled.
+// The returned lease must be released when the deployment has finished.
+func (r *Reservation) Acquire(ctx context.Context) (*Lease, error) {
+	defer metrics.QueueDepth.Dec()
+
+	for _, w := range r.waiters {
+		select {
+		case <-w.ready:
+		case <-ctx.Done():
+			r.unlock()
+			return nil, ctx.Err()
+		}
+	}
+
+	lease := &Lease{reservation: r}
+	err := lease.AcquireWorker(ctx)
+	if err != nil {
+		r.unlock()
+		return nil, err
+	}
+
+	metrics.QueueWait.Observe(time.Since(r.queued).Seconds())
+
+	return lease, nil
+}
+
+// AcquireWorker blocks until a worker is available, or the context is cancelled.
+// Does nothing if the lease already holds a worker.
+func (l *Lease) AcquireWorker(ctx context.Context) error {
+	if l.working {
+		return nil
+	}
+
+	select {
+	case l.reservation.scheduler.workers <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	l.working = true
+	metrics.WorkersBusy.Inc()
+
+	return nil
+}
+
+// ReleaseWorker lets other deployments use the worker, while the objects stay locked.
+// Does nothing if the lease does not hold a worker.
+func (l *Lease) ReleaseWorker() {
+	if !l.working {
+		return
+	}
+
+	l.working = false
+	metrics.WorkersBusy.Dec()
+	<-l.reservation.scheduler.workers
+}
+
+// Release gives up the worker, if any, and the locks. Must be called when the deployment has finished.
+func (l *Lease) Release() {
+	l.ReleaseWorker()
+	l.reservation.unlock()
+}
+
+// Leave the queues of all objects. If the reservation held an object, it is handed over to the next in line.
+func (r *Reservation) unlock() {
+	s := r.scheduler
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for i, key := range r.keys {
+		queue := s.objects[key]
+		for j, w := range queue {
+			if w != r.waiters[i] {
+				continue
+			}
+			queue = append(queue[:j], queue[j+1:]...)
+			if j == 0 && len(queue) > 0 {
+				close(queue[0].ready)
+			}
+			break
+		}
+
+		if len(queue) == 0 {
+			delete(s.objects, key)
+		} else {
+			s.objects[key] = queue
+		}
+	}
+}
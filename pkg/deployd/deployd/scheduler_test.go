@@ -0,0 +1,180 @@
+package deployd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Returns a channel that receives the release function once Acquire succeeds.
+func acquire(ctx context.Context, s *Scheduler, resources ...unstructured.Unstructured) chan func() {
+	acquired := make(chan func(), 1)
+	go func() {
+		lease, err := s.Acquire(ctx, resources)
+		if err == nil {
+			acquired <- lease.Release
+		}
+	}()
+	return acquired
+}
+
+func assertAcquired(t *testing.T, acquired chan func()) func() {
+	select {
+	case release := <-acquired:
+		return release
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not grant access within time")
+		return nil
+	}
+}
+
+func assertBlocked(t *testing.T, acquired chan func()) {
+	select {
+	case <-acquired:
+		t.Fatal("scheduler granted access while resources are in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSchedulerLocksObjects(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(10)
+	app := resource("nais.io/v1alpha1", "Application", "app", nil)
+	other := resource("nais.io/v1alpha1", "Application", "other", nil)
+
+	release := assertAcquired(t, acquire(ctx, s, app, other))
+
+	second := acquire(ctx, s, app)
+	assertBlocked(t, second)
+
+	unrelated := acquire(ctx, s, resource("v1", "ConfigMap", "app", nil))
+	assertAcquired(t, unrelated)()
+
+	release()
+	assertAcquired(t, second)()
+}
+
+func TestSchedulerConcurrency(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(1)
+
+	release := assertAcquired(t, acquire(ctx, s, resource("v1", "ConfigMap", "a", nil)))
+
+	second := acquire(ctx, s, resource("v1", "ConfigMap", "b", nil))
+	assertBlocked(t, second)
+
+	release()
+	assertAcquired(t, second)()
+}
+
+func TestSchedulerReleaseWorker(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(1)
+	a := resource("v1", "ConfigMap", "a", nil)
+
+	lease, err := s.Acquire(ctx, []unstructured.Unstructured{a})
+	assert.NoError(t, err)
+
+	// While the first deployment is monitored, the worker is free for other objects, but its own objects stay locked.
+	lease.ReleaseWorker()
+	sameObject := acquire(ctx, s, a)
+	release := assertAcquired(t, acquire(ctx, s, resource("v1", "ConfigMap", "b", nil)))
+	assertBlocked(t, sameObject)
+
+	// Getting the worker back waits for the other deployment to finish.
+	reacquired := make(chan error, 1)
+	go func() {
+		reacquired <- lease.AcquireWorker(ctx)
+	}()
+	select {
+	case <-reacquired:
+		t.Fatal("scheduler granted a worker while all workers are busy")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	assert.NoError(t, <-reacquired)
+	assertBlocked(t, sameObject)
+
+	lease.Release()
+	assertAcquired(t, sameObject)()
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := NewScheduler(1)
+	svc := resource("v1", "Service", "app", nil)
+
+	release := assertAcquired(t, acquire(context.Background(), s, svc))
+
+	// The ConfigMap is locked before the Service, which is in use.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.Acquire(ctx, []unstructured.Unstructured{svc, resource("v1", "ConfigMap", "config", nil)})
+	assert.Equal(t, context.Canceled, err)
+
+	// Locks taken before cancellation must be released.
+	release()
+	assertAcquired(t, acquire(context.Background(), s, resource("v1", "ConfigMap", "config", nil)))()
+}
+
+func TestObjectKeys(t *testing.T) {
+	keys := objectKeys([]unstructured.Unstructured{
+		resource("v1", "Service", "app", nil),
+		resource("nais.io/v1alpha1", "Application", "app", nil),
+		resource("v1", "Service", "app", nil),
+	})
+	assert.Equal(t, []objectKey{
+		{kind: "Service", name: "app"},
+		{group: "nais.io", kind: "Application", name: "app"},
+	}, keys)
+}
+
+func TestSchedulerDistinguishesGroups(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(10)
+
+	release := assertAcquired(t, acquire(ctx, s, resource("nais.io/v1alpha1", "Application", "app", nil)))
+	defer release()
+
+	assertAcquired(t, acquire(ctx, s, resource("example.com/v1", "Application", "app", nil)))()
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(10)
+	app := resource("nais.io/v1alpha1", "Application", "app", nil)
+
+	release := assertAcquired(t, acquire(ctx, s, app))
+
+	// Deployments waiting for the same object are granted access in the order they were reserved,
+	// regardless of when they start waiting.
+	reservations := make([]*Reservation, 5)
+	for i := range reservations {
+		reservations[i] = s.Reserve([]unstructured.Unstructured{app})
+	}
+
+	order := make(chan int, len(reservations))
+	for i := len(reservations) - 1; i >= 0; i-- {
+		go func(i int) {
+			lease, err := reservations[i].Acquire(ctx)
+			assert.NoError(t, err)
+			order <- i
+			time.Sleep(time.Millisecond * 10)
+			lease.Release()
+		}(i)
+	}
+
+	release()
+
+	for expected := range reservations {
+		select {
+		case i := <-order:
+			assert.Equal(t, expected, i)
+		case <-time.After(time.Second):
+			t.Fatal("scheduler did not grant access within time")
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package kubeclient
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/transport"
+)
+
+// Ways for deployd to authenticate as a team.
+const (
+	// Use the token stored in the secret of the team service account.
+	AuthSecret = "secret"
+
+	// Use the credentials of deployd itself, impersonating a user and groups derived from the team.
+	AuthImpersonate = "impersonate"
+
+	// Use short-lived tokens bound to the team service account, issued by the TokenRequest API.
+	AuthTokenRequest = "token-request"
+)
+
+// TeamAuth configures how deployd authenticates as a team.
+//
+// Impersonation templates may contain the placeholders {team} and {namespace},
+// where namespace is the namespace of the team service account.
+type TeamAuth struct {
+	Mode              string
+	ImpersonateUser   string
+	ImpersonateGroups []string
+	TokenExpiration   time.Duration
+}
+
+func (a TeamAuth) Validate() error {
+	switch a.Mode {
+	case AuthSecret:
+	case AuthImpersonate:
+		if len(a.ImpersonateUser) == 0 {
+			return fmt.Errorf("impersonation requires a user template")
+		}
+	case AuthTokenRequest:
+		// The API server refuses to issue tokens valid for less than ten minutes.
+		if a.TokenExpiration < time.Minute*10 {
+			return fmt.Errorf("token expiration must be at least 10 minutes")
+		}
+	default:
+		return fmt.Errorf("unknown team authentication mode '%s'; use one of %s, %s, %s", a.Mode, AuthSecret, AuthImpersonate, AuthTokenRequest)
+	}
+	return nil
+}
+
+func expandTemplate(template, team, namespace string) string {
+	return strings.NewReplacer("{team}", team, "{namespace}", namespace).Replace(template)
+}
+
+// Return a REST client configuration authenticated as a team, along with a string identifying the credentials.
+// Clients built from configurations with the same identity are interchangeable.
+func (c *Client) teamRESTConfig(team, namespace string, autoCreateServiceAccount bool) (*rest.Config, string, error) {
+	switch c.TeamAuth.Mode {
+	case AuthImpersonate:
+		return c.impersonationConfig(team, namespace)
+	case AuthTokenRequest:
+		return c.tokenRequestConfig(team, namespace, autoCreateServiceAccount)
+	case AuthSecret, "":
+		return c.secretConfig(team, namespace, autoCreateServiceAccount)
+	default:
+		return nil, "", fmt.Errorf("unknown team authentication mode '%s'", c.TeamAuth.Mode)
+	}
+}
+
+func (c *Client) secretConfig(team, namespace string, autoCreateServiceAccount bool) (*rest.Config, string, error) {
+	config, err := c.teamConfig(team, namespace, autoCreateServiceAccount)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rc, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("generating Kubernetes REST client config: %s", err)
+	}
+
+	return rc, rc.BearerToken, nil
+}
+
+func (c *Client) impersonationConfig(team, namespace string) (*rest.Config, string, error) {
+	impersonate := rest.ImpersonationConfig{
+		UserName: expandTemplate(c.TeamAuth.ImpersonateUser, team, namespace),
+		Groups:   make([]string, len(c.TeamAuth.ImpersonateGroups)),
+	}
+	for i, group := range c.TeamAuth.ImpersonateGroups {
+		impersonate.Groups[i] = expandTemplate(group, team, namespace)
+	}
+
+	rc := rest.CopyConfig(c.Config)
+	rc.Impersonate = impersonate
+
+	return rc, fmt.Sprintf("%s %v", impersonate.UserName, impersonate.Groups), nil
+}
+
+// Tokens are requested when first needed, and renewed shortly before they expire.
+// Clients can therefore be used for longer than the lifetime of a single token.
+func (c *Client) tokenRequestConfig(team, namespace string, autoCreateServiceAccount bool) (*rest.Config, string, error) {
+	serviceAccountName := serviceAccountName(team)
+
+	if autoCreateServiceAccount {
+		_, err := c.ensureServiceAccount(serviceAccountName, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	source := &tokenRequestSource{
+		client:     c.Base,
+		name:       serviceAccountName,
+		namespace:  namespace,
+		expiration: c.TeamAuth.TokenExpiration,
+	}
+
+	rc := &rest.Config{
+		Host: c.Config.Host,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure: c.Config.Insecure,
+			CAFile:   c.Config.CAFile,
+			CAData:   c.Config.CAData,
+		},
+		WrapTransport: transport.TokenSourceWrapTransport(oauth2.ReuseTokenSource(nil, source)),
+	}
+
+	return rc, fmt.Sprintf("%s/%s", namespace, serviceAccountName), nil
+}
+
+// Issues tokens for a service account using the TokenRequest API.
+type tokenRequestSource struct {
+	client     kubernetes.Interface
+	name       string
+	namespace  string
+	expiration time.Duration
+}
+
+func (s *tokenRequestSource) Token() (*oauth2.Token, error) {
+	seconds := int64(s.expiration.Seconds())
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &seconds,
+		},
+	}
+
+	response, err := s.client.CoreV1().ServiceAccounts(s.namespace).CreateToken(s.name, request)
+	if err != nil {
+		return nil, fmt.Errorf("while requesting service account token: %s", err)
+	}
+
+	return &oauth2.Token{
+		AccessToken: response.Status.Token,
+		Expiry:      response.Status.ExpirationTimestamp.Time,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package kubeclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTeamAuthValidate(t *testing.T) {
+	assert.NoError(t, TeamAuth{Mode: AuthSecret}.Validate())
+	assert.NoError(t, TeamAuth{Mode: AuthImpersonate, ImpersonateUser: "{team}"}.Validate())
+	assert.NoError(t, TeamAuth{Mode: AuthTokenRequest, TokenExpiration: time.Hour}.Validate())
+
+	assert.Error(t, TeamAuth{Mode: "password"}.Validate())
+	assert.Error(t, TeamAuth{Mode: AuthImpersonate}.Validate())
+	assert.Error(t, TeamAuth{Mode: AuthTokenRequest, TokenExpiration: time.Minute}.Validate())
+}
+
+func TestImpersonationConfig(t *testing.T) {
+	c := &Client{
+		Config: &rest.Config{Host: "https://kubernetes.local", BearerToken: "deployd"},
+		TeamAuth: TeamAuth{
+			Mode:              AuthImpersonate,
+			ImpersonateUser:   "system:serviceaccount:{namespace}:serviceuser-{team}",
+			ImpersonateGroups: []string{"system:serviceaccounts", "team:{team}"},
+		},
+	}
+
+	rc, identity, err := c.teamRESTConfig("myteam", "myteam", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "deployd", rc.BearerToken)
+	assert.Equal(t, "system:serviceaccount:myteam:serviceuser-myteam", rc.Impersonate.UserName)
+	assert.Equal(t, []string{"system:serviceaccounts", "team:myteam"}, rc.Impersonate.Groups)
+	assert.Equal(t, "system:serviceaccount:myteam:serviceuser-myteam [system:serviceaccounts team:myteam]", identity)
+
+	// The configuration of deployd itself must not be changed.
+	assert.Empty(t, c.Config.Impersonate.UserName)
+}
+
+func TestTokenRequestSource(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		request := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		assert.Equal(t, "token", action.GetSubresource())
+		assert.Equal(t, int64(3600), *request.Spec.ExpirationSeconds)
+		return true, &authenticationv1.TokenRequest{
+			Status: authenticationv1.TokenRequestStatus{
+				Token:               "short-lived",
+				ExpirationTimestamp: metav1.NewTime(expiry),
+			},
+		}, nil
+	})
+
+	source := &tokenRequestSource{
+		client:     client,
+		name:       serviceAccountName(team),
+		namespace:  namespace,
+		expiration: time.Hour,
+	}
+
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "short-lived", token.AccessToken)
+	assert.True(t, expiry.Equal(token.Expiry))
+}
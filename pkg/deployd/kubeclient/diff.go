@@ -0,0 +1,119 @@
+package kubeclient
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Metadata fields maintained by the API server, which would only add noise to a diff.
+var serverMetadata = []string{
+	"creationTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// Secret fields whose values must never end up in a diff.
+var secretFields = []string{"data", "stringData"}
+
+// Key for hashing secret values. Generated per process, so that the hashes in stored diffs
+// cannot be used to guess the values, while changed values still show up in the diff.
+var secretHashKey = func() []byte {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		panic(fmt.Sprintf("generate secret hash key: %s", err))
+	}
+	return key
+}()
+
+// Produce a unified diff between a live resource and the same resource after a deployment.
+// If the live resource is nil, the resource is considered to be new.
+// Returns an empty string if the deployment would not change anything.
+func diffResources(live, deployed *unstructured.Unstructured) (string, error) {
+	before, err := diffable(live)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := diffable(deployed)
+	if err != nil {
+		return "", err
+	}
+
+	name := resourceName(*deployed)
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before),
+		B:        difflib.SplitLines(after),
+		FromFile: "live/" + name,
+		ToFile:   "dry-run/" + name,
+		Context:  3,
+	})
+}
+
+// Render a resource as YAML, without status and server-maintained metadata.
+// The correlation ID annotation is removed as well, since it changes with every deployment.
+// Values of secrets are replaced with a hash.
+func diffable(resource *unstructured.Unstructured) (string, error) {
+	if resource == nil {
+		return "", nil
+	}
+
+	obj := resource.DeepCopy()
+	for _, field := range serverMetadata {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", CorrelationIDAnnotation)
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+	if isSecret(obj) {
+		redactSecret(obj)
+	}
+
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func isSecret(resource *unstructured.Unstructured) bool {
+	gvk := resource.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// Replace every value in a secret with a keyed hash of the value.
+func redactSecret(resource *unstructured.Unstructured) {
+	for _, field := range secretFields {
+		values, ok := resource.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range values {
+			mac := hmac.New(sha256.New, secretHashKey)
+			mac.Write([]byte(fmt.Sprint(value)))
+			values[key] = "redacted:" + hex.EncodeToString(mac.Sum(nil))[:16]
+		}
+	}
+}
+
+func resourceName(resource unstructured.Unstructured) string {
+	gvk := resource.GroupVersionKind()
+	if len(resource.GetNamespace()) == 0 {
+		return fmt.Sprintf("%s/%s/%s", gvk.GroupVersion().String(), gvk.Kind, resource.GetName())
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", gvk.GroupVersion().String(), gvk.Kind, resource.GetNamespace(), resource.GetName())
+}
@@ -0,0 +1,69 @@
+package kubeclient
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func secret(data, stringData map[string]interface{}) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "mysecret",
+			"namespace": namespace,
+		},
+	}}
+	if data != nil {
+		resource.Object["data"] = data
+	}
+	if stringData != nil {
+		resource.Object["stringData"] = stringData
+	}
+	return resource
+}
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	live := secret(map[string]interface{}{
+		"password": "bGl2ZS1wYXNzd29yZA==",
+		"username": "dXNlcg==",
+	}, nil)
+	deployed := secret(map[string]interface{}{
+		"password": "bmV3LXBhc3N3b3Jk",
+		"username": "dXNlcg==",
+	}, map[string]interface{}{
+		"token": "plaintext-token",
+	})
+
+	diff, err := diffResources(live, deployed)
+	assert.NoError(t, err)
+
+	for _, value := range []string{"bGl2ZS1wYXNzd29yZA==", "bmV3LXBhc3N3b3Jk", "dXNlcg==", "plaintext-token"} {
+		assert.NotContains(t, diff, value)
+	}
+	assert.Contains(t, diff, "password: redacted:")
+
+	// changed values are still visible as changes, unchanged values are not
+	changed := make([]string, 0)
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") {
+			changed = append(changed, strings.SplitN(line, ":", 2)[0])
+		}
+	}
+	assert.Equal(t, []string{"-  password", "+  password", "+stringData", "+  token"}, changed)
+}
+
+func TestDiffKeepsConfigMapData(t *testing.T) {
+	configMap := secret(map[string]interface{}{"key": "visible"}, nil)
+	configMap.SetKind("ConfigMap")
+
+	diff, err := diffResources(nil, configMap)
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "key: visible")
+}
@@ -0,0 +1,114 @@
+package kubeclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// Label set on every resource deployed as part of an inventory.
+	InventoryLabel = "nais.io/inventory"
+
+	inventoryNameTemplate = "deploy-inventory-%s"
+	inventoryKey          = "resources"
+)
+
+// InventoryEntry identifies a single resource applied by a deployment.
+type InventoryEntry struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+func NewInventoryEntry(resource unstructured.Unstructured) InventoryEntry {
+	gvk := resource.GroupVersionKind()
+	return InventoryEntry{
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: resource.GetNamespace(),
+		Name:      resource.GetName(),
+	}
+}
+
+// Two entries refer to the same resource even if they were deployed using different API versions.
+func (e InventoryEntry) SameResource(other InventoryEntry) bool {
+	return e.Group == other.Group && e.Kind == other.Kind && e.Namespace == other.Namespace && e.Name == other.Name
+}
+
+// Unstructured returns a resource skeleton with just enough information to look up the resource in the cluster.
+func (e InventoryEntry) Unstructured() unstructured.Unstructured {
+	resource := unstructured.Unstructured{}
+	resource.SetGroupVersionKind(schema.GroupVersionKind{Group: e.Group, Version: e.Version, Kind: e.Kind})
+	resource.SetNamespace(e.Namespace)
+	resource.SetName(e.Name)
+	return resource
+}
+
+func (e InventoryEntry) String() string {
+	return resourceName(e.Unstructured())
+}
+
+func inventoryName(id string) string {
+	return fmt.Sprintf(inventoryNameTemplate, id)
+}
+
+// Inventory returns the resources recorded by the previous deployment of an inventory,
+// or nil if the inventory does not exist.
+func (c *teamClient) Inventory(namespace, id string) ([]InventoryEntry, error) {
+	configMap, err := c.structuredClient.CoreV1().ConfigMaps(namespace).Get(inventoryName(id), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get inventory: %s", err)
+	}
+
+	entries := make([]InventoryEntry, 0)
+	err = json.Unmarshal([]byte(configMap.Data[inventoryKey]), &entries)
+	if err != nil {
+		return nil, fmt.Errorf("decode inventory: %s", err)
+	}
+
+	return entries, nil
+}
+
+// SaveInventory records the resources applied by a deployment, replacing any previous inventory with the same ID.
+func (c *teamClient) SaveInventory(namespace, id string, entries []InventoryEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("encode inventory: %s", err)
+	}
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      inventoryName(id),
+			Namespace: namespace,
+			Labels: map[string]string{
+				InventoryLabel: id,
+			},
+		},
+		Data: map[string]string{
+			inventoryKey: string(data),
+		},
+	}
+
+	client := c.structuredClient.CoreV1().ConfigMaps(namespace)
+
+	_, err = client.Create(configMap)
+	if errors.IsAlreadyExists(err) {
+		_, err = client.Update(configMap)
+	}
+	if err != nil {
+		return fmt.Errorf("save inventory: %s", err)
+	}
+
+	return nil
+}
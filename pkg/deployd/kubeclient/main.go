@@ -3,6 +3,7 @@ package kubeclient
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"k8s.io/api/core/v1"
@@ -14,6 +15,8 @@ import (
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth" // Needed for azure auth side effect
 
+	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"github.com/navikt/deployment/pkg/deployd/strategy"
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -31,10 +34,44 @@ var (
 type Client struct {
 	Base   kubernetes.Interface
 	Config *rest.Config
+
+	// Custom resources of these kinds are monitored according to their status conditions.
+	ReadinessRules strategy.ReadinessRules
+
+	// Other custom resources reporting status conditions are monitored according to this rule.
+	DefaultReadinessRule strategy.ReadinessRule
+
+	// Include container logs when explaining failed rollouts.
+	DiagnosisLogs bool
+
+	// Discovery information shared by all team clients.
+	RESTMapper *RESTMapper
+
+	// How deployd authenticates as a team.
+	TeamAuth TeamAuth
+
+	// Team clients are reused for this long before the team credentials are checked for changes.
+	TeamClientTTL time.Duration
+
+	lock        sync.Mutex
+	teamClients map[teamClientKey]*cachedTeamClient
+}
+
+type teamClientKey struct {
+	team            string
+	namespace       string
+	serverSideApply bool
+}
+
+// Cached clients are replaced when the identity of the team credentials changes, e.g. when a token is rotated.
+type cachedTeamClient struct {
+	client   TeamClient
+	identity string
+	checked  time.Time
 }
 
 type TeamClientProvider interface {
-	TeamClient(team, namespace string, autoCreateServiceAccount bool) (TeamClient, error)
+	TeamClient(team, namespace string, autoCreateServiceAccount, serverSideApply bool) (TeamClient, error)
 }
 
 func New() (*Client, error) {
@@ -49,8 +86,10 @@ func New() (*Client, error) {
 	}
 
 	return &Client{
-		Base:   client,
-		Config: config,
+		Base:        client,
+		Config:      config,
+		RESTMapper:  NewRESTMapper(client.Discovery()),
+		teamClients: make(map[teamClientKey]*cachedTeamClient),
 	}, nil
 }
 
@@ -64,10 +103,10 @@ func (c *Client) teamConfig(team, namespace string, autoCreateServiceAccount boo
 	// Kubernetes needs some time to generate the service account token,
 	// so we insert a small pause to wait for it.
 	if autoCreateServiceAccount {
-		_, err := createServiceAccount(c.Base, serviceAccountName, namespace)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return nil, fmt.Errorf("while generating service account: %s", err)
-		} else if err == nil {
+		created, err := c.ensureServiceAccount(serviceAccountName, namespace)
+		if err != nil {
+			return nil, err
+		} else if created {
 			time.Sleep(tokenGenerationTimeout)
 		}
 	}
@@ -103,24 +142,77 @@ func (c *Client) teamConfig(team, namespace string, autoCreateServiceAccount boo
 	return teamConfig, nil
 }
 
+// Create the service account if it does not exist, and report whether it was created.
+func (c *Client) ensureServiceAccount(serviceAccountName, namespace string) (bool, error) {
+	_, err := createServiceAccount(c.Base, serviceAccountName, namespace)
+	if errors.IsAlreadyExists(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("while generating service account: %s", err)
+	}
+	return true, nil
+}
+
 // TeamClient returns a Kubernetes REST client tailored for a specific team.
-// The user is the `serviceuser-TEAM` in the `default` namespace.
-func (c *Client) TeamClient(team, namespace string, autoCreateServiceAccount bool) (TeamClient, error) {
-	config, err := c.teamConfig(team, namespace, autoCreateServiceAccount)
+// The user is the `serviceuser-TEAM` in the `default` namespace, authenticated according to TeamAuth.
+// If serverSideApply is set, resources are deployed using server-side apply.
+//
+// Clients are cached. After TeamClientTTL has passed, the team credentials are read again,
+// and a new client is only created if they have changed.
+func (c *Client) TeamClient(team, namespace string, autoCreateServiceAccount, serverSideApply bool) (TeamClient, error) {
+	key := teamClientKey{
+		team:            team,
+		namespace:       namespace,
+		serverSideApply: serverSideApply,
+	}
+
+	c.lock.Lock()
+	cached := c.teamClients[key]
+	c.lock.Unlock()
+
+	if cached != nil && time.Since(cached.checked) < c.TeamClientTTL {
+		metrics.TeamClientCacheHits.Inc()
+		return cached.client, nil
+	}
+
+	config, identity, err := c.teamRESTConfig(team, namespace, autoCreateServiceAccount)
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := clientcmd.Write(*config)
-	if err != nil {
-		return nil, fmt.Errorf("generating team Kubeconfig: %s", err)
+	if cached != nil && cached.identity == identity {
+		metrics.TeamClientCacheHits.Inc()
+		c.store(key, cached.client, identity)
+		return cached.client, nil
 	}
 
-	rc, err := clientcmd.RESTConfigFromKubeConfig(output)
+	metrics.TeamClientCacheMisses.Inc()
+
+	client, err := c.newTeamClient(config, serverSideApply)
 	if err != nil {
-		return nil, fmt.Errorf("generating Kubernetes REST client config: %s", err)
+		return nil, err
+	}
+
+	c.store(key, client, identity)
+
+	return client, nil
+}
+
+func (c *Client) store(key teamClientKey, client TeamClient, identity string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.teamClients == nil {
+		c.teamClients = make(map[teamClientKey]*cachedTeamClient)
 	}
+	c.teamClients[key] = &cachedTeamClient{
+		client:   client,
+		identity: identity,
+		checked:  time.Now(),
+	}
+}
 
+func (c *Client) newTeamClient(rc *rest.Config, serverSideApply bool) (TeamClient, error) {
 	k, err := kubernetes.NewForConfig(rc)
 	if err != nil {
 		return nil, fmt.Errorf("unable to generate Kubernetes client: %s", err)
@@ -131,9 +223,19 @@ func (c *Client) TeamClient(team, namespace string, autoCreateServiceAccount boo
 		return nil, fmt.Errorf("unable to generate dynamic client: %s", err)
 	}
 
+	restMapper := c.RESTMapper
+	if restMapper == nil {
+		restMapper = NewRESTMapper(k.Discovery())
+	}
+
 	return &teamClient{
 		structuredClient:   k,
 		unstructuredClient: d,
+		restMapper:         restMapper,
+		serverSideApply:    serverSideApply,
+		readinessRules:     c.ReadinessRules,
+		defaultReadiness:   c.DefaultReadinessRule,
+		diagnosisLogs:      c.DiagnosisLogs,
 	}, nil
 }
 
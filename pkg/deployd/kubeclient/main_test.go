@@ -0,0 +1,95 @@
+package kubeclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	team      = "myteam"
+	namespace = "default"
+)
+
+func newFakeClient(ttl time.Duration) *Client {
+	base := fake.NewSimpleClientset(
+		&v1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName(team), Namespace: namespace},
+			Secrets:    []v1.ObjectReference{{Name: "token"}},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: namespace},
+			Data:       map[string][]byte{"token": []byte("first")},
+		},
+	)
+	return &Client{
+		Base:          base,
+		Config:        &rest.Config{Host: "https://kubernetes.local"},
+		RESTMapper:    NewRESTMapper(base.Discovery()),
+		TeamClientTTL: ttl,
+	}
+}
+
+func rotateToken(t *testing.T, c *Client, token string) {
+	secrets := c.Base.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get("token", metav1.GetOptions{})
+	assert.NoError(t, err)
+	secret.Data["token"] = []byte(token)
+	_, err = secrets.Update(secret)
+	assert.NoError(t, err)
+}
+
+func TestTeamClientCache(t *testing.T) {
+	t.Run("clients are reused within the TTL, even if the token changes", func(t *testing.T) {
+		c := newFakeClient(time.Hour)
+
+		first, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+
+		rotateToken(t, c, "second")
+
+		second, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+		assert.True(t, first == second)
+	})
+
+	t.Run("clients are reused after the TTL if the token is unchanged", func(t *testing.T) {
+		c := newFakeClient(0)
+
+		first, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+
+		second, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+		assert.True(t, first == second)
+	})
+
+	t.Run("clients are replaced after the TTL if the token has changed", func(t *testing.T) {
+		c := newFakeClient(0)
+
+		first, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+
+		rotateToken(t, c, "second")
+
+		second, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+		assert.False(t, first == second)
+	})
+
+	t.Run("clients with different settings are cached separately", func(t *testing.T) {
+		c := newFakeClient(time.Hour)
+
+		first, err := c.TeamClient(team, namespace, false, false)
+		assert.NoError(t, err)
+
+		second, err := c.TeamClient(team, namespace, false, true)
+		assert.NoError(t, err)
+		assert.False(t, first == second)
+	})
+}
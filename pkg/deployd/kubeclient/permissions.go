@@ -0,0 +1,99 @@
+package kubeclient
+
+import (
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Permission is an action a deployment needs to perform on a resource.
+type Permission struct {
+	Verb     string
+	Resource InventoryEntry
+}
+
+func (p Permission) String() string {
+	return fmt.Sprintf("%s %s", p.Verb, p.Resource)
+}
+
+// InventoryPermissions returns the permissions needed to read and update an inventory.
+func InventoryPermissions(namespace, id string) []Permission {
+	configMap := InventoryEntry{
+		Version:   "v1",
+		Kind:      "ConfigMap",
+		Namespace: namespace,
+		Name:      inventoryName(id),
+	}
+	return []Permission{
+		{Verb: "get", Resource: configMap},
+		{Verb: "create", Resource: configMap},
+		{Verb: "update", Resource: configMap},
+	}
+}
+
+// MissingPermissions asks the API server whether the team is allowed to perform each action,
+// and returns the ones that would be denied.
+//
+// Resources of unknown kinds are skipped, as their custom resource definitions may be part of the same deployment.
+func (c *teamClient) MissingPermissions(permissions []Permission) ([]Permission, error) {
+	missing := make([]Permission, 0)
+	checked := make(map[authorizationv1.ResourceAttributes]bool)
+	seen := make(map[Permission]bool)
+
+	for _, permission := range permissions {
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+
+		resource := permission.Resource.Unstructured()
+		mapping, err := c.restMapper.RESTMapping(resource.GroupVersionKind())
+		if meta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to discover resource using REST mapper: %s", err)
+		}
+
+		attributes := resourceAttributes(permission, mapping.Resource)
+		if allowed, ok := checked[attributes]; ok {
+			if !allowed {
+				missing = append(missing, permission)
+			}
+			continue
+		}
+
+		review, err := c.structuredClient.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &attributes,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("review access to %s: %s", permission, err)
+		}
+
+		checked[attributes] = review.Status.Allowed
+		if !review.Status.Allowed {
+			missing = append(missing, permission)
+		}
+	}
+
+	return missing, nil
+}
+
+// The name of a resource is not known to the authorizer when it is created, so it is left out of create checks.
+func resourceAttributes(permission Permission, gvr schema.GroupVersionResource) authorizationv1.ResourceAttributes {
+	attributes := authorizationv1.ResourceAttributes{
+		Namespace: permission.Resource.Namespace,
+		Verb:      permission.Verb,
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+		Name:      permission.Resource.Name,
+	}
+	if permission.Verb == "create" {
+		attributes.Name = ""
+	}
+	return attributes
+}
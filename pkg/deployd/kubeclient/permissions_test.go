@@ -0,0 +1,61 @@
+package kubeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			},
+		},
+	}
+
+	reviews := make([]authorizationv1.ResourceAttributes, 0)
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := *review.Spec.ResourceAttributes
+		reviews = append(reviews, attributes)
+		review.Status.Allowed = attributes.Verb != "delete"
+		return true, review, nil
+	})
+
+	c := &teamClient{
+		structuredClient: client,
+		restMapper:       NewRESTMapper(client.Discovery()),
+	}
+
+	configMap := InventoryEntry{Version: "v1", Kind: "ConfigMap", Namespace: "aura", Name: "config"}
+	application := InventoryEntry{Group: "nais.io", Version: "v1alpha1", Kind: "Application", Namespace: "aura", Name: "app"}
+
+	missing, err := c.MissingPermissions([]Permission{
+		{Verb: "create", Resource: configMap},
+		{Verb: "delete", Resource: configMap},
+		{Verb: "delete", Resource: configMap},
+		{Verb: "create", Resource: application},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Permission{
+		{Verb: "delete", Resource: configMap},
+	}, missing)
+
+	// Identical checks are only reviewed once, names are left out of create checks,
+	// and kinds unknown to the cluster are not reviewed at all.
+	assert.Equal(t, []authorizationv1.ResourceAttributes{
+		{Namespace: "aura", Verb: "create", Version: "v1", Resource: "configmaps"},
+		{Namespace: "aura", Verb: "delete", Version: "v1", Resource: "configmaps", Name: "config"},
+	}, reviews)
+}
@@ -0,0 +1,110 @@
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/navikt/deployment/pkg/deployd/metrics"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+)
+
+// Unknown kinds trigger discovery at most this often, so that misspelled kinds cannot flood the API server.
+var missRefreshInterval = time.Second * 10
+
+// RESTMapper maps resource kinds to their API endpoints using discovery information cached in memory,
+// and shared between all team clients. Discovery is only run again when the cache is refreshed,
+// or when a kind cannot be found, which happens when a resource depends on a newly created custom resource definition.
+type RESTMapper struct {
+	discovery discovery.CachedDiscoveryInterface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+
+	lock        sync.Mutex
+	lastRefresh time.Time
+}
+
+func NewRESTMapper(client discovery.DiscoveryInterface) *RESTMapper {
+	cached := memory.NewMemCacheClient(client)
+	return &RESTMapper{
+		discovery: cached,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(cached),
+	}
+}
+
+// RESTMapping returns the API endpoint of a resource kind, refreshing the discovery information once if the kind is unknown,
+// unless it has been refreshed within the last missRefreshInterval.
+func (m *RESTMapper) RESTMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := m.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if !meta.IsNoMatchError(err) {
+		return mapping, err
+	}
+
+	refreshed, refreshErr := m.refreshIfStale(missRefreshInterval)
+	if refreshErr != nil {
+		return nil, refreshErr
+	} else if !refreshed {
+		return nil, err
+	}
+
+	return m.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// Refresh discards the cached discovery information and runs discovery again, unless a refresh completes in the meantime.
+func (m *RESTMapper) Refresh() error {
+	_, err := m.refreshIfStale(0)
+	return err
+}
+
+// Run discovery again, unless it has already run within the given interval.
+// Concurrent callers wait for a refresh in progress, and share its outcome.
+// Returns true if the discovery information is fresh.
+func (m *RESTMapper) refreshIfStale(interval time.Duration) (bool, error) {
+	requested := time.Now()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.lastRefresh.After(requested) {
+		return true, nil
+	}
+	if time.Since(m.lastRefresh) < interval {
+		return false, nil
+	}
+
+	m.mapper.Reset()
+
+	started := time.Now()
+	_, err := restmapper.GetAPIGroupResources(m.discovery)
+	metrics.DiscoveryDuration.Observe(time.Since(started).Seconds())
+	m.lastRefresh = time.Now()
+
+	if err != nil {
+		return false, fmt.Errorf("unable to run kubernetes resource discovery: %s", err)
+	}
+	return true, nil
+}
+
+// Run refreshes the discovery information at the given interval, until the context is cancelled.
+func (m *RESTMapper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := m.Refresh()
+		if err != nil {
+			log.Errorf("Refreshing REST mapper: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package kubeclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Number of times the API groups have been discovered.
+func discoveries(client *fakediscovery.FakeDiscovery) int {
+	count := 0
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource == "group" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRESTMapperRateLimitsRefreshes(t *testing.T) {
+	defer func(interval time.Duration) {
+		missRefreshInterval = interval
+	}(missRefreshInterval)
+	missRefreshInterval = time.Millisecond * 100
+
+	client := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	client.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+		},
+	}
+
+	mapper := NewRESTMapper(client)
+	assert.NoError(t, mapper.Refresh())
+	assert.Equal(t, 1, discoveries(client))
+
+	typo := schema.GroupVersionKind{Group: "nais.io", Version: "v1alpha1", Kind: "Aplication"}
+
+	// Unknown kinds do not trigger discovery right after a refresh.
+	for i := 0; i < 10; i++ {
+		_, err := mapper.RESTMapping(typo)
+		assert.True(t, meta.IsNoMatchError(err), err)
+	}
+	assert.Equal(t, 1, discoveries(client))
+
+	// Once the interval has passed, an unknown kind triggers discovery, and a newly created kind is found.
+	time.Sleep(missRefreshInterval)
+	client.Resources = append(client.Resources, &metav1.APIResourceList{
+		GroupVersion: "nais.io/v1alpha1",
+		APIResources: []metav1.APIResource{{Name: "applications", Kind: "Application", Namespaced: true}},
+	})
+
+	mapping, err := mapper.RESTMapping(schema.GroupVersionKind{Group: "nais.io", Version: "v1alpha1", Kind: "Application"})
+	assert.NoError(t, err)
+	assert.Equal(t, "applications", mapping.Resource.Resource)
+	assert.Equal(t, 2, discoveries(client))
+
+	_, err = mapper.RESTMapping(typo)
+	assert.True(t, meta.IsNoMatchError(err), err)
+	assert.Equal(t, 2, discoveries(client))
+
+	// Scheduled refreshes are not rate limited.
+	assert.NoError(t, mapper.Refresh())
+	assert.Equal(t, 3, discoveries(client))
+}
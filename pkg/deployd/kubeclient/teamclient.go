@@ -1,16 +1,17 @@
 package kubeclient
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/navikt/deployment/pkg/deployd/strategy"
 	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/restmapper"
 )
 
 const (
@@ -20,45 +21,172 @@ const (
 type teamClient struct {
 	structuredClient   kubernetes.Interface
 	unstructuredClient dynamic.Interface
+	restMapper         *RESTMapper
+	serverSideApply    bool
+	readinessRules     strategy.ReadinessRules
+	defaultReadiness   strategy.ReadinessRule
+	diagnosisLogs      bool
 }
 
 type TeamClient interface {
-	DeployUnstructured(resource unstructured.Unstructured) (*unstructured.Unstructured, error)
-	WaitForDeployment(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error
+	DeployUnstructured(resource unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, string, error)
+	LiveResource(resource unstructured.Unstructured) (*unstructured.Unstructured, error)
+	RestoreUnstructured(resource unstructured.Unstructured, snapshot *unstructured.Unstructured) (*unstructured.Unstructured, error)
+	DeleteUnstructured(resource unstructured.Unstructured) error
+	Inventory(namespace, id string) ([]InventoryEntry, error)
+	SaveInventory(namespace, id string, entries []InventoryEntry) error
+	MissingPermissions(permissions []Permission) ([]Permission, error)
+	WaitForDeployment(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error
+	WaitForEstablished(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error
 }
 
 // Implement TeamClient interface
 var _ TeamClient = &teamClient{}
 
+// Discover the location of a resource using the shared Kubernetes API REST mapper,
+// and return a client for its resource type and namespace.
+func (c *teamClient) resourceClient(resource unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	mapping, err := c.restMapper.RESTMapping(resource.GroupVersionKind())
+	if err != nil {
+		return nil, fmt.Errorf("unable to discover resource using REST mapper: %s", err)
+	}
+
+	clusterResource := c.unstructuredClient.Resource(mapping.Resource)
+	ns := resource.GetNamespace()
+
+	if len(ns) == 0 {
+		return clusterResource, nil
+	}
+	return clusterResource.Namespace(ns), nil
+}
+
 // DeployUnstructured takes a generic unstructured object, discovers its location
 // using the Kubernetes API REST mapper, and deploys it to the cluster.
-func (c *teamClient) DeployUnstructured(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
-	groupResources, err := restmapper.GetAPIGroupResources(c.structuredClient.Discovery())
+//
+// If dryRun is set, the resource is validated by the API server but not persisted,
+// and a unified diff between the live resource and the resource as it would be deployed is returned.
+func (c *teamClient) DeployUnstructured(resource unstructured.Unstructured, dryRun bool) (*unstructured.Unstructured, string, error) {
+	client, err := c.resourceClient(resource)
 	if err != nil {
-		return nil, fmt.Errorf("unable to run kubernetes resource discovery: %s", err)
+		return nil, "", err
 	}
-	restMapper := restmapper.NewDiscoveryRESTMapper(groupResources)
 
 	gvk := resource.GroupVersionKind()
-	gk := schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}
-	mapping, err := restMapper.RESTMapping(gk, gvk.Version)
+	deployStrategy := strategy.NewDeployStrategy(gvk, client, c.serverSideApply, dryRun)
+
+	if !dryRun {
+		deployed, err := deployStrategy.Deploy(resource)
+		return deployed, "", err
+	}
+
+	live, err := liveResource(client, resource)
 	if err != nil {
-		return nil, fmt.Errorf("unable to discover resource using REST mapper: %s", err)
+		return nil, "", err
 	}
 
-	clusterResource := c.unstructuredClient.Resource(mapping.Resource)
-	ns := resource.GetNamespace()
+	deployed, err := deployStrategy.Deploy(resource)
+	if err != nil {
+		return nil, "", err
+	}
 
-	if len(ns) == 0 {
-		return strategy.NewDeployStrategy(gvk, clusterResource).Deploy(resource)
-	} else {
-		return strategy.NewDeployStrategy(gvk, clusterResource.Namespace(ns)).Deploy(resource)
+	diff, err := diffResources(live, deployed)
+	if err != nil {
+		return nil, "", fmt.Errorf("compute diff: %s", err)
+	}
+
+	return deployed, diff, nil
+}
+
+// LiveResource returns the resource as it currently exists in the cluster, or nil if it does not exist.
+func (c *teamClient) LiveResource(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	client, err := c.resourceClient(resource)
+	if err != nil {
+		return nil, err
+	}
+	return liveResource(client, resource)
+}
+
+func liveResource(client dynamic.ResourceInterface, resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	live, err := client.Get(resource.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get live resource: %s", err)
+	}
+	return live, nil
+}
+
+// RestoreUnstructured puts a resource back the way it was before a deployment, as recorded by LiveResource.
+// If the snapshot is nil, the resource did not exist beforehand and is deleted.
+//
+// Snapshots hold the complete resource, so it is overwritten regardless of the server-side apply setting.
+func (c *teamClient) RestoreUnstructured(resource unstructured.Unstructured, snapshot *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if snapshot == nil {
+		return nil, c.DeleteUnstructured(resource)
+	}
+
+	client, err := c.resourceClient(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := snapshot.DeepCopy()
+	for _, field := range serverMetadata {
+		unstructured.RemoveNestedField(restored.Object, "metadata", field)
 	}
+	unstructured.RemoveNestedField(restored.Object, "status")
+
+	return strategy.NewDeployStrategy(resource.GroupVersionKind(), client, false, false).Deploy(*restored)
+}
+
+// DeleteUnstructured removes a resource from the cluster. Resources that do not exist are ignored.
+func (c *teamClient) DeleteUnstructured(resource unstructured.Unstructured) error {
+	client, err := c.resourceClient(resource)
+	if err != nil {
+		return err
+	}
+
+	err = client.Delete(resource.GetName(), &metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
 }
 
 // Returns nil after the next generation of the deployment is successfully rolled out,
-// or error if it has not succeeded within the specified deadline.
-func (c *teamClient) WaitForDeployment(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+// or error if it has not succeeded within the specified deadline, or the context is cancelled.
+// Kinds with a readiness rule are monitored through their status conditions.
+// Other custom resources are monitored according to the default readiness rule, if they report status conditions.
+func (c *teamClient) WaitForDeployment(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
 	gvk := resource.GroupVersionKind()
-	return strategy.NewWatchStrategy(gvk, c.structuredClient, c.unstructuredClient).Watch(logger, resource, deadline)
+
+	if rule, ok := c.readinessRules[gvk.GroupKind()]; ok {
+		client, err := c.resourceClient(resource)
+		if err != nil {
+			return err
+		}
+		return strategy.NewConditionStrategy(client, rule).Watch(ctx, logger, resource, deadline)
+	}
+
+	watchStrategy := strategy.NewWatchStrategy(gvk, c.structuredClient, c.unstructuredClient, c.diagnosisLogs)
+
+	if _, unknown := watchStrategy.(strategy.NoOp); unknown && strategy.IsCustomResource(gvk) {
+		client, err := c.resourceClient(resource)
+		if err != nil {
+			return err
+		}
+		watchStrategy = strategy.NewDefaultConditionStrategy(client, c.defaultReadiness)
+	}
+
+	return watchStrategy.Watch(ctx, logger, resource, deadline)
+}
+
+// Returns nil when the resource is ready to be depended upon by resources deployed after it,
+// or error if it has not become ready within the specified deadline, or the context is cancelled.
+func (c *teamClient) WaitForEstablished(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	client, err := c.resourceClient(resource)
+	if err != nil {
+		return err
+	}
+	return strategy.WaitForEstablished(ctx, logger, client, resource, deadline)
 }
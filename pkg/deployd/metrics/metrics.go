@@ -21,18 +21,62 @@ func counter(name, help string) prometheus.Counter {
 	})
 }
 
+func gauge(name, help string) prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:      name,
+		Help:      help,
+		Namespace: namespace,
+		Subsystem: subsystem,
+	})
+}
+
+func histogram(name, help string, buckets []float64) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:      name,
+		Help:      help,
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Buckets:   buckets,
+	})
+}
+
 var (
 	DeploySuccessful    = counter("deploy_successful", "number of successful deployments")
 	DeployFailed        = counter("deploy_failed", "number of failed deployments")
 	DeployIgnored       = counter("deploy_ignored", "number of ignored/discarded deployments")
+	DeployCancelled     = counter("deploy_cancelled", "number of cancelled deployments")
+	DeployRolledBack    = counter("deploy_rolled_back", "number of failed deployments rolled back to the previous version")
+	DeployResumed       = counter("deploy_resumed", "number of deployments resumed after a restart of deployd")
 	KubernetesResources = counter("kubernetes_resources", "number of Kubernetes resources successfully committed to cluster")
+
+	QueueDepth  = gauge("queue_depth", "number of deployments waiting for a worker or for objects locked by another deployment")
+	QueueWait   = histogram("queue_wait_seconds", "time deployments spend waiting for a worker and object locks", prometheus.ExponentialBuckets(0.1, 2, 15))
+	WorkersBusy = gauge("workers_busy", "number of deployments currently making changes to the cluster")
+
+	StatusesPending = gauge("statuses_pending", "number of deployment statuses waiting to be delivered to hookd")
+	StatusesDropped = counter("statuses_dropped", "number of deployment statuses given up on without being delivered to hookd")
+
+	TeamClientCacheHits   = counter("team_client_cache_hits", "number of deployments reusing a cached team client")
+	TeamClientCacheMisses = counter("team_client_cache_misses", "number of deployments requiring a new team client")
+	DiscoveryDuration     = histogram("discovery_seconds", "time spent running Kubernetes API discovery", prometheus.ExponentialBuckets(0.05, 2, 12))
 )
 
 func init() {
 	prometheus.MustRegister(DeploySuccessful)
 	prometheus.MustRegister(DeployFailed)
 	prometheus.MustRegister(DeployIgnored)
+	prometheus.MustRegister(DeployCancelled)
+	prometheus.MustRegister(DeployRolledBack)
+	prometheus.MustRegister(DeployResumed)
 	prometheus.MustRegister(KubernetesResources)
+	prometheus.MustRegister(QueueDepth)
+	prometheus.MustRegister(QueueWait)
+	prometheus.MustRegister(WorkersBusy)
+	prometheus.MustRegister(StatusesPending)
+	prometheus.MustRegister(StatusesDropped)
+	prometheus.MustRegister(TeamClientCacheHits)
+	prometheus.MustRegister(TeamClientCacheMisses)
+	prometheus.MustRegister(DiscoveryDuration)
 }
 
 func Handler() http.Handler {
@@ -0,0 +1,291 @@
+// Package outbox delivers deployment statuses from deployd to hookd.
+//
+// Statuses are delivered in order for each deployment, while deployments are independent of each other,
+// so that a single undeliverable status does not hold back the rest. Failed deliveries are retried with
+// exponential backoff. Every status is given a unique ID, which lets hookd discard statuses delivered twice.
+//
+// If a spool directory is configured, statuses are written to disk until delivered,
+// and statuses left over from a previous run are delivered on startup.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/navikt/deployment/pkg/deployd/metrics"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+const spoolExtension = ".status"
+
+var (
+	// Delay before the first retry of a failed delivery. The delay doubles on each attempt, up to backoffMax.
+	backoffMin = time.Millisecond * 500
+	backoffMax = time.Minute
+
+	// Statuses older than this are dropped instead of retried.
+	maxAge = time.Hour * 24
+)
+
+// Sender delivers a single status to hookd.
+type Sender func(ctx context.Context, status *pb.DeploymentStatus) error
+
+type Outbox struct {
+	send   Sender
+	spool  string
+	lock   sync.Mutex
+	queues map[string][]*pb.DeploymentStatus
+}
+
+// New creates an outbox delivering statuses using the sender. If spool is not empty,
+// undelivered statuses from a previous run are read from the spool directory and queued.
+func New(send Sender, spool string) (*Outbox, error) {
+	o := &Outbox{
+		send:   send,
+		spool:  spool,
+		queues: make(map[string][]*pb.DeploymentStatus),
+	}
+
+	if len(spool) == 0 {
+		return o, nil
+	}
+
+	err := os.MkdirAll(spool, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("create status spool: %s", err)
+	}
+
+	statuses, err := o.readSpool()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statuses) > 0 {
+		log.Infof("Delivering %d deployment statuses left over from previous run", len(statuses))
+	}
+
+	for _, status := range statuses {
+		o.enqueue(status)
+	}
+
+	return o, nil
+}
+
+// Add queues a status for delivery, after any statuses already queued for the same deployment.
+// Progress statuses are only attempted once, and never written to the spool.
+func (o *Outbox) Add(status *pb.DeploymentStatus) {
+	if len(status.GetId()) == 0 {
+		status.Id = uuid.New().String()
+	}
+
+	if !status.GetProgress() {
+		err := o.writeSpool(status)
+		if err != nil {
+			log.WithFields(status.LogFields()).Errorf("Deployment status is not persisted, and will be lost on restart: %s", err)
+		}
+	}
+
+	o.enqueue(status)
+}
+
+// Pending returns the number of statuses waiting to be delivered.
+func (o *Outbox) Pending() int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	pending := 0
+	for _, queue := range o.queues {
+		pending += len(queue)
+	}
+	return pending
+}
+
+// Flush waits until all queued statuses have been delivered, or the context is done.
+func (o *Outbox) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+
+	for o.Pending() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d deployment statuses not delivered: %s", o.Pending(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+func (o *Outbox) enqueue(status *pb.DeploymentStatus) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	deliveryID := status.GetDeliveryID()
+	queue, busy := o.queues[deliveryID]
+	o.queues[deliveryID] = append(queue, status)
+	metrics.StatusesPending.Inc()
+
+	if !busy {
+		go o.deliver(deliveryID)
+	}
+}
+
+// Return the next status for a deployment, or nil if there are none left.
+// The queue is forgotten once empty, so that the next status starts a new delivery loop.
+func (o *Outbox) next(deliveryID string) *pb.DeploymentStatus {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	queue := o.queues[deliveryID]
+	if len(queue) == 0 {
+		delete(o.queues, deliveryID)
+		return nil
+	}
+	return queue[0]
+}
+
+func (o *Outbox) done(status *pb.DeploymentStatus) {
+	o.lock.Lock()
+	deliveryID := status.GetDeliveryID()
+	o.queues[deliveryID] = o.queues[deliveryID][1:]
+	metrics.StatusesPending.Dec()
+	o.lock.Unlock()
+
+	err := o.removeSpool(status)
+	if err != nil {
+		log.WithFields(status.LogFields()).Errorf("Unable to remove delivered status from spool: %s", err)
+	}
+}
+
+// Deliver the statuses of one deployment in order, until there are none left.
+func (o *Outbox) deliver(deliveryID string) {
+	attempt := 0
+
+	for status := o.next(deliveryID); status != nil; status = o.next(deliveryID) {
+		logger := log.WithFields(status.LogFields())
+
+		ctx, cancel := context.WithTimeout(context.Background(), backoffMax)
+		err := o.send(ctx, status)
+		cancel()
+
+		switch {
+		case err == nil:
+			logger.Infof("Deployment response sent successfully")
+		case status.GetProgress():
+			metrics.StatusesDropped.Inc()
+			logger.Warnf("Dropping progress status: %s", err)
+		case status.GetTime() != nil && time.Since(status.Timestamp()) > maxAge:
+			metrics.StatusesDropped.Inc()
+			logger.Errorf("Dropping deployment status not delivered within %s: %s", maxAge, err)
+		default:
+			delay := backoff(attempt)
+			attempt++
+			logger.Errorf("While reporting deployment status: %s; retrying in %s", err, delay)
+			time.Sleep(delay)
+			continue
+		}
+
+		attempt = 0
+		o.done(status)
+	}
+}
+
+// Exponential backoff with jitter. The delay is between half and all of the exponential delay,
+// so that deliveries failing at the same time are spread out when they are retried.
+func backoff(attempt int) time.Duration {
+	delay := backoffMax
+	if attempt < 32 {
+		delay = backoffMin << uint(attempt)
+	}
+	if delay > backoffMax || delay <= 0 {
+		delay = backoffMax
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Spool files are named after the status time, so that sorting by name restores the original order.
+func (o *Outbox) spoolPath(status *pb.DeploymentStatus) string {
+	name := fmt.Sprintf("%020d-%s%s", status.Timestamp().UnixNano(), status.GetId(), spoolExtension)
+	return filepath.Join(o.spool, name)
+}
+
+func (o *Outbox) writeSpool(status *pb.DeploymentStatus) error {
+	if len(o.spool) == 0 {
+		return nil
+	}
+
+	data, err := proto.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a crash never leaves a partial status behind.
+	path := o.spoolPath(status)
+	err = ioutil.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func (o *Outbox) removeSpool(status *pb.DeploymentStatus) error {
+	if len(o.spool) == 0 || status.GetProgress() {
+		return nil
+	}
+
+	err := os.Remove(o.spoolPath(status))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (o *Outbox) readSpool() ([]*pb.DeploymentStatus, error) {
+	files, err := ioutil.ReadDir(o.spool)
+	if err != nil {
+		return nil, fmt.Errorf("read status spool: %s", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), spoolExtension) {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	statuses := make([]*pb.DeploymentStatus, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(o.spool, name)
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read status spool: %s", err)
+		}
+
+		status := &pb.DeploymentStatus{}
+		err = proto.Unmarshal(data, status)
+		if err != nil {
+			metrics.StatusesDropped.Inc()
+			log.Errorf("Dropping unreadable status %s from spool: %s", name, err)
+			os.Remove(path)
+			continue
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
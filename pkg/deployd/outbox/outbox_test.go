@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// Records delivered statuses, and fails the first attempts of each deployment.
+type recorder struct {
+	lock      sync.Mutex
+	failures  map[string]int
+	delivered []*pb.DeploymentStatus
+}
+
+func (r *recorder) send(ctx context.Context, status *pb.DeploymentStatus) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.failures[status.GetDeliveryID()] > 0 {
+		r.failures[status.GetDeliveryID()]--
+		return fmt.Errorf("hookd unavailable")
+	}
+	r.delivered = append(r.delivered, status)
+	return nil
+}
+
+func (r *recorder) descriptions(deliveryID string) []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	descriptions := make([]string, 0)
+	for _, status := range r.delivered {
+		if status.GetDeliveryID() == deliveryID {
+			descriptions = append(descriptions, status.GetDescription())
+		}
+	}
+	return descriptions
+}
+
+func status(deliveryID, description string) *pb.DeploymentStatus {
+	return &pb.DeploymentStatus{
+		DeliveryID:  deliveryID,
+		Description: description,
+		State:       pb.GithubDeploymentState_in_progress,
+		Time:        pb.TimeAsTimestamp(time.Now()),
+	}
+}
+
+func flush(t *testing.T, o *Outbox) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, o.Flush(ctx))
+}
+
+func init() {
+	backoffMin = time.Millisecond
+	backoffMax = time.Millisecond * 10
+}
+
+func TestOrderedDelivery(t *testing.T) {
+	r := &recorder{failures: map[string]int{"a": 3}}
+	o, err := New(r.send, "")
+	assert.NoError(t, err)
+
+	o.Add(status("a", "1"))
+	o.Add(status("b", "1"))
+	o.Add(status("a", "2"))
+	o.Add(status("b", "2"))
+	o.Add(status("a", "3"))
+
+	flush(t, o)
+
+	assert.Equal(t, []string{"1", "2", "3"}, r.descriptions("a"))
+	assert.Equal(t, []string{"1", "2"}, r.descriptions("b"))
+	for _, status := range r.delivered {
+		assert.NotEmpty(t, status.GetId())
+	}
+}
+
+func TestProgressIsNotRetried(t *testing.T) {
+	r := &recorder{failures: map[string]int{"a": 1}}
+	o, err := New(r.send, "")
+	assert.NoError(t, err)
+
+	progress := status("a", "progress")
+	progress.Progress = true
+	o.Add(progress)
+	o.Add(status("a", "final"))
+
+	flush(t, o)
+
+	assert.Equal(t, []string{"final"}, r.descriptions("a"))
+}
+
+func TestSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outbox")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Statuses that are never delivered stay in the spool.
+	unavailable := &recorder{failures: map[string]int{"a": 1 << 30}}
+	o, err := New(unavailable.send, dir)
+	assert.NoError(t, err)
+
+	o.Add(status("a", "1"))
+	o.Add(status("a", "2"))
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// A new outbox using the same spool delivers them in the original order.
+	r := &recorder{}
+	o, err = New(r.send, dir)
+	assert.NoError(t, err)
+
+	flush(t, o)
+
+	assert.Equal(t, []string{"1", "2"}, r.descriptions("a"))
+
+	files, err = ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+// Outboxes from other tests may still be retrying, so the backoff settings from init are used as they are.
+func TestBackoff(t *testing.T) {
+	for attempt, expected := range []time.Duration{backoffMin, 2 * backoffMin, 4 * backoffMin} {
+		delay := backoff(attempt)
+		assert.True(t, delay >= expected/2 && delay <= expected, "attempt %d: %s", attempt, delay)
+	}
+	assert.True(t, backoff(100) <= backoffMax)
+}
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -9,6 +10,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -91,10 +93,7 @@ func (a application) getApplicationEvent(resource unstructured.Unstructured, rea
 	return event, nil
 }
 
-func (a application) Watch(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
-	var updated *unstructured.Unstructured
-	var err error
-	var status *appStatus
+func (a application) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
 	var pickedup bool
 
 	correlationID, _ := resource.GetAnnotations()[CorrelationIDAnnotation]
@@ -106,42 +105,44 @@ func (a application) Watch(logger *log.Entry, resource unstructured.Unstructured
 		Group:    gvk.Group,
 	}).Namespace(resource.GetNamespace())
 
-	for deadline.After(time.Now()) {
-		updated, err = appcli.Get(resource.GetName(), metav1.GetOptions{})
+	get := func() (runtime.Object, error) {
+		return appcli.Get(resource.GetName(), metav1.GetOptions{})
+	}
 
-		if err != nil {
-			logger.Tracef("Retrieving updated Application resource %s: %s", resource.GetSelfLink(), err)
-			goto NEXT
+	check := func(obj runtime.Object) (bool, error) {
+		updated, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return false, nil
 		}
 
-		status = parseAppStatus(*updated)
+		status := parseAppStatus(*updated)
 		if status == nil || status.CorrelationID != correlationID {
 			if pickedup {
-				return fmt.Errorf("Application resource has been overwritten, aborting monitoring.")
+				return true, fmt.Errorf("Application resource has been overwritten, aborting monitoring.")
 			}
 			logger.Tracef("Application correlation ID mismatch; not picked up by Naiserator yet.")
-			goto NEXT
+			return false, nil
 		}
 
 		pickedup = true
 		logger.Tracef("Application synchronization state: '%s'", status.SynchronizationState)
+		reportProgress(ctx, "synchronization state: %s", status.SynchronizationState)
 
 		switch status.SynchronizationState {
 		case EventRolloutComplete:
-			return nil
+			return true, nil
 
 		case EventFailedSynchronization, EventFailedPrepare:
 			event, err := a.getApplicationEvent(*updated, status.SynchronizationState)
 			if err != nil {
 				logger.Errorf("Get application event: %s", err)
-				return fmt.Errorf(status.SynchronizationState)
+				return true, fmt.Errorf(status.SynchronizationState)
 			}
-			return fmt.Errorf("%s", event.Message)
+			return true, fmt.Errorf("%s", event.Message)
 		}
 
-	NEXT:
-		time.Sleep(requestInterval)
-		continue
+		return false, nil
 	}
-	return ErrDeploymentTimeout
+
+	return watchUntil(ctx, logger, resource.GetName(), get, appcli.Watch, deadline, check)
 }
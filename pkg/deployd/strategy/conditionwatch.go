@@ -0,0 +1,217 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	DefaultReadyCondition  = "Ready"
+	DefaultFailedCondition = "Stalled"
+)
+
+var (
+	// How long to wait for a custom resource without a readiness rule to start reporting conditions.
+	conditionsGracePeriod = time.Second * 15
+)
+
+// ReadinessRule tells how to decide whether a custom resource is ready, based on the conditions in its status.
+// Resources are ready when the ready condition is True, and have failed when the failed condition is True.
+// If the ready condition is empty, resources are not monitored at all.
+type ReadinessRule struct {
+	ReadyCondition  string
+	FailedCondition string
+}
+
+// ReadinessRules maps custom resource kinds to their readiness rules.
+type ReadinessRules map[schema.GroupKind]ReadinessRule
+
+// ParseReadinessRules parses rules on the form KIND.GROUP=READY[/FAILED], e.g. 'Topic.kafka.nais.io=Ready/Failed'.
+// The failed condition defaults to 'Stalled'. Leaving out the conditions altogether disables monitoring of the kind.
+func ParseReadinessRules(rules []string) (ReadinessRules, error) {
+	parsed := make(ReadinessRules)
+
+	for _, rule := range rules {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("readiness rule '%s' must be on the form KIND.GROUP=READY[/FAILED]", rule)
+		}
+
+		gk := schema.ParseGroupKind(parts[0])
+		if len(gk.Kind) == 0 {
+			return nil, fmt.Errorf("readiness rule '%s' does not specify a kind", rule)
+		}
+
+		parsed[gk] = ParseReadinessRule(parts[1])
+	}
+
+	return parsed, nil
+}
+
+// ParseReadinessRule parses the conditions of a rule on the form READY[/FAILED], e.g. 'Ready/Failed'.
+// The failed condition defaults to 'Stalled'. An empty string gives a rule that disables monitoring.
+func ParseReadinessRule(conditions string) ReadinessRule {
+	parts := strings.SplitN(conditions, "/", 2)
+	rule := ReadinessRule{
+		ReadyCondition:  parts[0],
+		FailedCondition: DefaultFailedCondition,
+	}
+	if len(parts) == 2 {
+		rule.FailedCondition = parts[1]
+	}
+	return rule
+}
+
+// IsCustomResource tells whether a kind is defined by a custom resource definition rather than by Kubernetes itself.
+// Custom resources must belong to a fully qualified group outside of k8s.io.
+func IsCustomResource(gvk schema.GroupVersionKind) bool {
+	return strings.Contains(gvk.Group, ".") && gvk.Group != "k8s.io" && !strings.HasSuffix(gvk.Group, ".k8s.io")
+}
+
+type condition struct {
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	ObservedGeneration int64
+}
+
+func parseConditions(resource unstructured.Unstructured) map[string]condition {
+	conditions := make(map[string]condition)
+	list, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := condition{}
+		c.Type, _ = fields["type"].(string)
+		c.Status, _ = fields["status"].(string)
+		c.Reason, _ = fields["reason"].(string)
+		c.Message, _ = fields["message"].(string)
+		c.ObservedGeneration, _, _ = unstructured.NestedInt64(fields, "observedGeneration")
+		conditions[c.Type] = c
+	}
+
+	return conditions
+}
+
+// Ready checks a version of a resource against the rule. Returns true if the rollout is finished,
+// along with an error if the resource reports failure.
+//
+// Status that has not yet been updated for the current generation of the resource, as told by
+// status.observedGeneration or the observedGeneration of the condition itself, is disregarded.
+func (r ReadinessRule) Ready(resource unstructured.Unstructured) (bool, error) {
+	generation := resource.GetGeneration()
+
+	observed, found, _ := unstructured.NestedInt64(resource.Object, "status", "observedGeneration")
+	if found && observed < generation {
+		return false, nil
+	}
+
+	conditions := parseConditions(resource)
+	current := func(c condition) bool {
+		return c.ObservedGeneration == 0 || c.ObservedGeneration >= generation
+	}
+
+	if c, ok := conditions[r.FailedCondition]; ok && c.Status == string(metav1.ConditionTrue) && current(c) {
+		return true, fmt.Errorf("%s: %s", c.Reason, c.Message)
+	}
+
+	if c, ok := conditions[r.ReadyCondition]; ok && c.Status == string(metav1.ConditionTrue) && current(c) {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+type conditions struct {
+	client dynamic.ResourceInterface
+	rule   ReadinessRule
+}
+
+// NewConditionStrategy returns a watch strategy that monitors any resource according to a readiness rule.
+func NewConditionStrategy(client dynamic.ResourceInterface, rule ReadinessRule) WatchStrategy {
+	if len(rule.ReadyCondition) == 0 {
+		return NoOp{}
+	}
+	return conditions{client: client, rule: rule}
+}
+
+func (c conditions) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	get := func() (runtime.Object, error) {
+		return c.client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	check := func(obj runtime.Object) (bool, error) {
+		updated, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return false, nil
+		}
+
+		done, err := c.rule.Ready(*updated)
+		if !done {
+			logger.Tracef("Still waiting for %s to report condition '%s'...", resource.GetKind(), c.rule.ReadyCondition)
+			if ready, ok := parseConditions(*updated)[c.rule.ReadyCondition]; ok && len(ready.Reason) > 0 {
+				reportProgress(ctx, "%s: %s", ready.Reason, ready.Message)
+			}
+		}
+		return done, err
+	}
+
+	return watchUntil(ctx, logger, resource.GetName(), get, c.client.Watch, deadline, check)
+}
+
+type defaultConditions struct {
+	conditions
+}
+
+// NewDefaultConditionStrategy returns a watch strategy for custom resources without a readiness rule of their own.
+// Not all controllers report status conditions, so resources are only monitored according to the rule if they report
+// any conditions within a short grace period. Resources that do not are considered ready.
+func NewDefaultConditionStrategy(client dynamic.ResourceInterface, rule ReadinessRule) WatchStrategy {
+	if len(rule.ReadyCondition) == 0 {
+		return NoOp{}
+	}
+	return defaultConditions{conditions{client: client, rule: rule}}
+}
+
+func (c defaultConditions) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	get := func() (runtime.Object, error) {
+		return c.client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	hasConditions := func(obj runtime.Object) (bool, error) {
+		updated, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return false, nil
+		}
+		_, found, _ := unstructured.NestedSlice(updated.Object, "status", "conditions")
+		return found, nil
+	}
+
+	grace := time.Now().Add(conditionsGracePeriod)
+	if grace.After(deadline) {
+		grace = deadline
+	}
+
+	err := watchUntil(ctx, logger, resource.GetName(), get, c.client.Watch, grace, hasConditions)
+	if err == ErrDeploymentTimeout {
+		logger.Infof("%s does not report status conditions; not monitoring rollout", resource.GetKind())
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.conditions.Watch(ctx, logger, resource, deadline)
+}
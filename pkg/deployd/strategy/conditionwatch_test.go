@@ -0,0 +1,177 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseReadinessRules(t *testing.T) {
+	rules, err := ParseReadinessRules([]string{
+		"Topic.kafka.nais.io=Ready",
+		"postgresql.acid.zalan.do=Available/Failed",
+		"Alert.nais.io=",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, ReadinessRules{
+		{Group: "kafka.nais.io", Kind: "Topic"}:      {ReadyCondition: "Ready", FailedCondition: "Stalled"},
+		{Group: "acid.zalan.do", Kind: "postgresql"}: {ReadyCondition: "Available", FailedCondition: "Failed"},
+		{Group: "nais.io", Kind: "Alert"}:            {ReadyCondition: "", FailedCondition: "Stalled"},
+	}, rules)
+
+	_, err = ParseReadinessRules([]string{"Topic.kafka.nais.io"})
+	assert.Error(t, err)
+
+	_, err = ParseReadinessRules([]string{"=Ready"})
+	assert.Error(t, err)
+}
+
+func TestReadinessRuleReady(t *testing.T) {
+	rule := ReadinessRule{ReadyCondition: "Ready", FailedCondition: "Stalled"}
+
+	resource := func(status map[string]interface{}) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: "kafka.nais.io", Version: "v1", Kind: "Topic"})
+		u.SetGeneration(2)
+		return u
+	}
+	conditions := func(c ...map[string]interface{}) []interface{} {
+		list := make([]interface{}, len(c))
+		for i := range c {
+			list[i] = c[i]
+		}
+		return list
+	}
+
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		done   bool
+		err    string
+	}{
+		{
+			name:   "no status",
+			status: map[string]interface{}{},
+		},
+		{
+			name: "ready",
+			status: map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions":         conditions(map[string]interface{}{"type": "Ready", "status": "True"}),
+			},
+			done: true,
+		},
+		{
+			name: "not ready",
+			status: map[string]interface{}{
+				"conditions": conditions(map[string]interface{}{"type": "Ready", "status": "False"}),
+			},
+		},
+		{
+			name: "generation not observed",
+			status: map[string]interface{}{
+				"observedGeneration": int64(1),
+				"conditions":         conditions(map[string]interface{}{"type": "Ready", "status": "True"}),
+			},
+		},
+		{
+			name: "condition from previous generation",
+			status: map[string]interface{}{
+				"conditions": conditions(map[string]interface{}{"type": "Ready", "status": "True", "observedGeneration": int64(1)}),
+			},
+		},
+		{
+			name: "failed",
+			status: map[string]interface{}{
+				"conditions": conditions(
+					map[string]interface{}{"type": "Ready", "status": "False"},
+					map[string]interface{}{"type": "Stalled", "status": "True", "reason": "InvalidSpec", "message": "partitions must be positive"},
+				),
+			},
+			done: true,
+			err:  "InvalidSpec: partitions must be positive",
+		},
+	}
+
+	for _, test := range tests {
+		done, err := rule.Ready(resource(test.status))
+		assert.Equal(t, test.done, done, test.name)
+		if len(test.err) > 0 {
+			assert.EqualError(t, err, test.err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+	}
+}
+
+func TestIsCustomResource(t *testing.T) {
+	assert.True(t, IsCustomResource(schema.GroupVersionKind{Group: "kafka.nais.io", Version: "v1", Kind: "Topic"}))
+	assert.True(t, IsCustomResource(schema.GroupVersionKind{Group: "nais.io", Version: "v1", Kind: "Alert"}))
+	assert.False(t, IsCustomResource(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+	assert.False(t, IsCustomResource(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+	assert.False(t, IsCustomResource(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}))
+}
+
+func TestDefaultConditionStrategy(t *testing.T) {
+	defer func(grace time.Duration) {
+		conditionsGracePeriod = grace
+	}(conditionsGracePeriod)
+	conditionsGracePeriod = 50 * time.Millisecond
+
+	gvr := schema.GroupVersionResource{Group: "kafka.nais.io", Version: "v1", Resource: "topics"}
+	topic := func(conditions ...interface{}) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetGroupVersionKind(schema.GroupVersionKind{Group: "kafka.nais.io", Version: "v1", Kind: "Topic"})
+		u.SetNamespace("aura")
+		u.SetName("topic")
+		if len(conditions) > 0 {
+			unstructured.SetNestedSlice(u.Object, conditions, "status", "conditions")
+		}
+		return u
+	}
+
+	tests := []struct {
+		name     string
+		resource *unstructured.Unstructured
+		err      string
+	}{
+		{
+			name:     "no conditions",
+			resource: topic(),
+		},
+		{
+			name:     "ready",
+			resource: topic(map[string]interface{}{"type": "Ready", "status": "True"}),
+		},
+		{
+			name:     "failed",
+			resource: topic(map[string]interface{}{"type": "Stalled", "status": "True", "reason": "InvalidSpec", "message": "partitions must be positive"}),
+			err:      "InvalidSpec: partitions must be positive",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), test.resource)
+			watchStrategy := NewDefaultConditionStrategy(client.Resource(gvr).Namespace("aura"), ParseReadinessRule("Ready"))
+
+			err := watchStrategy.Watch(context.Background(), log.NewEntry(log.New()), *test.resource, time.Now().Add(time.Second))
+			if len(test.err) > 0 {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Equal(t, NoOp{}, NewDefaultConditionStrategy(nil, ParseReadinessRule("")))
+	})
+}
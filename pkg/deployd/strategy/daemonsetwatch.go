@@ -0,0 +1,68 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+type daemonSet struct {
+	client kubernetes.Interface
+}
+
+func (d daemonSet) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	client := d.client.AppsV1().DaemonSets(resource.GetNamespace())
+
+	get := func() (runtime.Object, error) {
+		return client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	check := func(obj runtime.Object) (bool, error) {
+		set, ok := obj.(*apps.DaemonSet)
+		if !ok {
+			return false, nil
+		}
+
+		if daemonSetComplete(set) {
+			return true, nil
+		}
+
+		logger.WithFields(log.Fields{
+			"daemonset_desired_scheduled":   set.Status.DesiredNumberScheduled,
+			"daemonset_updated_scheduled":   set.Status.UpdatedNumberScheduled,
+			"daemonset_available":           set.Status.NumberAvailable,
+			"daemonset_observed_generation": set.Status.ObservedGeneration,
+		}).Tracef("Still waiting for daemon set to finish rollout...")
+
+		reportProgress(ctx, "%d/%d pods updated, %d available", set.Status.UpdatedNumberScheduled, set.Status.DesiredNumberScheduled, set.Status.NumberAvailable)
+
+		return false, nil
+	}
+
+	return watchUntil(ctx, logger, resource.GetName(), get, client.Watch, deadline, check)
+}
+
+// daemonSetComplete considers a daemon set to be complete once the controller has observed the latest generation,
+// and an updated pod is available on every node it is scheduled to. With the OnDelete strategy,
+// pods are only replaced when deleted by the user, so updates are not waited for.
+//
+// Adapted from
+// https://github.com/kubernetes/kubernetes/blob/v1.15.0/pkg/kubectl/rollout_status.go#L95
+func daemonSetComplete(set *apps.DaemonSet) bool {
+	if set.Status.ObservedGeneration < set.Generation {
+		return false
+	}
+
+	if set.Spec.UpdateStrategy.Type != apps.RollingUpdateDaemonSetStrategyType {
+		return true
+	}
+
+	return set.Status.UpdatedNumberScheduled >= set.Status.DesiredNumberScheduled &&
+		set.Status.NumberAvailable >= set.Status.DesiredNumberScheduled
+}
@@ -0,0 +1,37 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDaemonSetComplete(t *testing.T) {
+	set := func(strategy apps.DaemonSetUpdateStrategyType, status apps.DaemonSetStatus) *apps.DaemonSet {
+		return &apps.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec: apps.DaemonSetSpec{
+				UpdateStrategy: apps.DaemonSetUpdateStrategy{Type: strategy},
+			},
+			Status: status,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		set      *apps.DaemonSet
+		complete bool
+	}{
+		{"generation not observed", set(apps.RollingUpdateDaemonSetStrategyType, apps.DaemonSetStatus{ObservedGeneration: 1, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}), false},
+		{"pods not updated", set(apps.RollingUpdateDaemonSetStrategyType, apps.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 2, NumberAvailable: 3}), false},
+		{"pods not available", set(apps.RollingUpdateDaemonSetStrategyType, apps.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2}), false},
+		{"rolled out", set(apps.RollingUpdateDaemonSetStrategyType, apps.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 3}), true},
+		{"on delete", set(apps.OnDeleteDaemonSetStrategyType, apps.DaemonSetStatus{ObservedGeneration: 2, DesiredNumberScheduled: 3}), true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.complete, daemonSetComplete(test.set), test.name)
+	}
+}
@@ -2,19 +2,52 @@ package strategy
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 )
 
-func NewDeployStrategy(gvk schema.GroupVersionKind, namespacedResource dynamic.ResourceInterface) DeployStrategy {
+const (
+	// Name recorded as the owner of all fields set through server-side apply.
+	FieldManager = "nais-deploy"
+)
+
+var (
+	ErrApplyConflict = fmt.Errorf("fields in this resource are managed by another controller or user")
+)
+
+// NewDeployStrategy returns the strategy used to deploy a resource.
+// If serverSideApply is set, resources are applied server-side, leaving fields owned by others alone.
+// Otherwise, existing resources are completely overwritten.
+// If dryRun is set, all requests are validated by the API server, but no changes are persisted.
+func NewDeployStrategy(gvk schema.GroupVersionKind, namespacedResource dynamic.ResourceInterface, serverSideApply, dryRun bool) DeployStrategy {
+	var dryRunOption []string
+	if dryRun {
+		dryRunOption = []string{metav1.DryRunAll}
+	}
+
 	if gvk.Group == "batch" && gvk.Version == "v1" && gvk.Kind == "Job" {
-		return recreateStrategy{client: namespacedResource}
+		return recreateStrategy{client: namespacedResource, dryRun: dryRunOption}
+	} else if serverSideApply {
+		return serverSideApplyStrategy{client: namespacedResource, dryRun: dryRunOption}
 	} else {
-		return createOrUpdateStrategy{client: namespacedResource}
+		return createOrUpdateStrategy{client: namespacedResource, dryRun: dryRunOption}
+	}
+}
+
+// DeployVerbs returns the API verbs used by the strategy NewDeployStrategy picks for a resource.
+func DeployVerbs(gvk schema.GroupVersionKind, serverSideApply bool) []string {
+	if gvk.Group == "batch" && gvk.Version == "v1" && gvk.Kind == "Job" {
+		return []string{"delete", "create"}
+	} else if serverSideApply {
+		return []string{"create", "get", "patch"}
+	} else {
+		return []string{"create", "get", "update"}
 	}
 }
 
@@ -24,13 +57,24 @@ type DeployStrategy interface {
 
 type recreateStrategy struct {
 	client dynamic.ResourceInterface
+	dryRun []string
 }
 
 type createOrUpdateStrategy struct {
 	client dynamic.ResourceInterface
+	dryRun []string
+}
+
+type serverSideApplyStrategy struct {
+	client dynamic.ResourceInterface
+	dryRun []string
 }
 
 func (r recreateStrategy) Deploy(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if len(r.dryRun) > 0 {
+		return r.validate(resource)
+	}
+
 	err := r.client.Delete(resource.GetName(), &metav1.DeleteOptions{})
 	if !errors.IsNotFound(err) {
 		return nil, err
@@ -38,8 +82,20 @@ func (r recreateStrategy) Deploy(resource unstructured.Unstructured) (*unstructu
 	return r.client.Create(&resource, metav1.CreateOptions{})
 }
 
+// Dry runs cannot validate the new resource while the old one is still present, as the deletion is never persisted.
+// In that case, only the deletion is validated, and the resource is returned as it was submitted.
+func (r recreateStrategy) validate(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	err := r.client.Delete(resource.GetName(), &metav1.DeleteOptions{DryRun: r.dryRun})
+	if errors.IsNotFound(err) {
+		return r.client.Create(&resource, metav1.CreateOptions{DryRun: r.dryRun})
+	} else if err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
 func (c createOrUpdateStrategy) Deploy(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
-	deployed, err := c.client.Create(&resource, metav1.CreateOptions{})
+	deployed, err := c.client.Create(&resource, metav1.CreateOptions{DryRun: c.dryRun})
 	if !errors.IsAlreadyExists(err) {
 		return deployed, err
 	}
@@ -49,5 +105,82 @@ func (c createOrUpdateStrategy) Deploy(resource unstructured.Unstructured) (*uns
 		return nil, fmt.Errorf("get existing resource: %s", err)
 	}
 	resource.SetResourceVersion(existing.GetResourceVersion())
-	return c.client.Update(&resource, metav1.UpdateOptions{})
+	return c.client.Update(&resource, metav1.UpdateOptions{DryRun: c.dryRun})
+}
+
+// Apply the resource server-side. Conflicts with fields owned by other field managers are not overridden,
+// but reported back as an error listing the conflicting fields.
+//
+// Resources deployed before server-side apply was enabled were completely overwritten on every deployment,
+// so their fields belong to whoever made the last update. The first apply to such a resource takes over the
+// conflicting fields, just as the previous update would have done; after that, conflicts are reported.
+func (s serverSideApplyStrategy) Deploy(resource unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, err := resource.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("encode resource: %s", err)
+	}
+
+	apply := func(force bool) (*unstructured.Unstructured, error) {
+		options := metav1.PatchOptions{
+			FieldManager: FieldManager,
+			DryRun:       s.dryRun,
+		}
+		if force {
+			options.Force = &force
+		}
+		return s.client.Patch(resource.GetName(), types.ApplyPatchType, data, options)
+	}
+
+	deployed, err := apply(false)
+	if errors.IsConflict(err) {
+		applied, gerr := s.appliedBefore(resource.GetName())
+		if gerr != nil {
+			return nil, fmt.Errorf("get existing resource: %s", gerr)
+		}
+		if !applied {
+			deployed, err = apply(true)
+		}
+	}
+	if errors.IsConflict(err) {
+		return nil, applyConflict(err)
+	}
+
+	return deployed, err
+}
+
+// Returns true if the existing resource has been applied server-side by deployd before.
+func (s serverSideApplyStrategy) appliedBefore(name string) (bool, error) {
+	existing, err := s.client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range existing.GetManagedFields() {
+		if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Summarize the conflicts reported by the API server.
+func applyConflict(err error) error {
+	status, ok := err.(errors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return fmt.Errorf("%s: %s", ErrApplyConflict, err)
+	}
+
+	conflicts := make([]string, 0)
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, cause.Message)
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return fmt.Errorf("%s: %s", ErrApplyConflict, err)
+	}
+
+	return fmt.Errorf("%s: %s", ErrApplyConflict, strings.Join(conflicts, "; "))
 }
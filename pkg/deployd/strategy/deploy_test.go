@@ -0,0 +1,202 @@
+package strategy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var configMapResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// The fake dynamic client does not record patch options, so they are captured on the way through.
+type patchRecorder struct {
+	dynamic.ResourceInterface
+	options []metav1.PatchOptions
+}
+
+func (r *patchRecorder) Patch(name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	r.options = append(r.options, options)
+	return r.ResourceInterface.Patch(name, pt, data, options, subresources...)
+}
+
+func applyConfigMap() unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]interface{}{
+		"data": map[string]interface{}{"key": "value"},
+	}}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetNamespace("aura")
+	u.SetName("config")
+	return u
+}
+
+// A config map as last deployed by the given field manager and operation.
+func managedConfigMap(manager string, operation metav1.ManagedFieldsOperationType) *unstructured.Unstructured {
+	u := applyConfigMap()
+	u.Object["data"] = map[string]interface{}{"key": "old"}
+	u.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: manager, Operation: operation}})
+	return &u
+}
+
+func conflictError(messages ...string) error {
+	causes := make([]metav1.StatusCause, 0, len(messages)+1)
+	for _, message := range messages {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldManagerConflict, Message: message})
+	}
+	causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid, Message: "not a conflict"})
+
+	err := errors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "config", nil)
+	err.ErrStatus.Details.Causes = causes
+	return err
+}
+
+func TestServerSideApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		dryRun   bool
+		response error
+		err      string
+	}{
+		{
+			name: "apply",
+		},
+		{
+			name:   "dry run",
+			dryRun: true,
+		},
+		{
+			name:     "conflicts are summarized",
+			response: conflictError(`conflict with "kubectl": .data.key`, `conflict with "controller": .data.other`),
+			err:      `fields in this resource are managed by another controller or user: conflict with "kubectl": .data.key; conflict with "controller": .data.other`,
+		},
+		{
+			name:     "conflicts without causes",
+			response: errors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "config", nil),
+			err:      `fields in this resource are managed by another controller or user: Operation cannot be fulfilled on configmaps "config": <nil>`,
+		},
+		{
+			name:     "other errors are passed through",
+			response: errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "config", nil),
+			err:      `configmaps "config" is forbidden: <nil>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := applyConfigMap()
+
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), managedConfigMap(FieldManager, metav1.ManagedFieldsOperationApply))
+			client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patch := action.(k8stesting.PatchAction)
+				assert.Equal(t, types.ApplyPatchType, patch.GetPatchType())
+				assert.Equal(t, "config", patch.GetName())
+				assert.Equal(t, "aura", patch.GetNamespace())
+
+				applied := &unstructured.Unstructured{}
+				assert.NoError(t, json.Unmarshal(patch.GetPatch(), &applied.Object))
+				assert.Equal(t, resource.Object, applied.Object)
+
+				if test.response != nil {
+					return true, nil, test.response
+				}
+				return true, applied, nil
+			})
+
+			recorder := &patchRecorder{ResourceInterface: client.Resource(configMapResource).Namespace("aura")}
+			deployStrategy := NewDeployStrategy(resource.GroupVersionKind(), recorder, true, test.dryRun)
+			assert.IsType(t, serverSideApplyStrategy{}, deployStrategy)
+
+			deployed, err := deployStrategy.Deploy(resource)
+
+			if len(test.err) > 0 {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "config", deployed.GetName())
+			}
+
+			// Conflicts are reported rather than overridden, so the apply is never forced.
+			expected := metav1.PatchOptions{FieldManager: FieldManager}
+			if test.dryRun {
+				expected.DryRun = []string{metav1.DryRunAll}
+			}
+			assert.Equal(t, []metav1.PatchOptions{expected}, recorder.options)
+			assert.Nil(t, recorder.options[0].Force)
+			assert.Equal(t, "nais-deploy", recorder.options[0].FieldManager)
+		})
+	}
+}
+
+func TestServerSideApplyTakesOverUpdatedResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *unstructured.Unstructured
+		forced   []bool
+		err      string
+	}{
+		{
+			name:   "first apply to a resource deployed with create or update",
+			forced: []bool{false, true},
+		},
+		{
+			name:     "resource applied before",
+			existing: managedConfigMap(FieldManager, metav1.ManagedFieldsOperationApply),
+			forced:   []bool{false},
+			err:      `fields in this resource are managed by another controller or user: conflict with "controller": .data.key`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			namespaced := client.Resource(configMapResource).Namespace("aura")
+			recorder := &patchRecorder{ResourceInterface: namespaced}
+
+			if test.existing != nil {
+				_, err := namespaced.Create(test.existing, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			} else {
+				// The create or update strategy overwrites the resource, and the API server records the update.
+				existing := managedConfigMap("deployd", metav1.ManagedFieldsOperationUpdate)
+				_, err := NewDeployStrategy(existing.GroupVersionKind(), namespaced, false, false).Deploy(*existing)
+				assert.NoError(t, err)
+			}
+
+			// Changing a field last set by someone else is a conflict, unless the apply is forced.
+			client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				options := recorder.options[len(recorder.options)-1]
+				if options.Force == nil || !*options.Force {
+					return true, nil, conflictError(`conflict with "controller": .data.key`)
+				}
+				applied := &unstructured.Unstructured{}
+				assert.NoError(t, json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &applied.Object))
+				return true, applied, nil
+			})
+
+			deployed, err := NewDeployStrategy(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, recorder, true, false).Deploy(applyConfigMap())
+
+			if len(test.err) > 0 {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "value", deployed.Object["data"].(map[string]interface{})["key"])
+			}
+
+			forced := make([]bool, len(recorder.options))
+			for i, options := range recorder.options {
+				forced[i] = options.Force != nil && *options.Force
+			}
+			assert.Equal(t, test.forced, forced)
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -10,16 +12,17 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 )
 
 type deployment struct {
 	client kubernetes.Interface
+	logs   logFunc
 }
 
-func (d deployment) Watch(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+func (d deployment) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
 	var cur *apps.Deployment
-	var nova *apps.Deployment
 	var err error
 	var resourceVersion int
 	var updated bool
@@ -36,29 +39,42 @@ func (d deployment) Watch(logger *log.Entry, resource unstructured.Unstructured,
 			logger.Tracef("Deployment '%s' in namespace '%s' is not currently present in the cluster.", resource.GetName(), resource.GetNamespace())
 		} else {
 			logger.Tracef("Recoverable error while polling for deployment object: %s", err)
-			time.Sleep(requestInterval)
+			if err := sleep(ctx); err != nil {
+				return err
+			}
 			continue
 		}
 		break
 	}
 
-	// Wait until the new deployment object is present in the cluster.
-	for deadline.After(time.Now()) {
-		nova, err = client.Get(resource.GetName(), metav1.GetOptions{})
-		if err != nil {
-			time.Sleep(requestInterval)
-			continue
+	get := func() (runtime.Object, error) {
+		return client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	// Wait until the new deployment object is present in the cluster, and is completely rolled out.
+	check := func(obj runtime.Object) (bool, error) {
+		nova, ok := obj.(*apps.Deployment)
+		if !ok {
+			return false, nil
 		}
 
 		rv, _ := strconv.Atoi(nova.GetResourceVersion())
 		if rv > resourceVersion {
-			logger.Tracef("New deployment appeared at version %d: %s", rv, cur.GetSelfLink())
+			logger.Tracef("New deployment appeared at version %d: %s", rv, nova.GetSelfLink())
 			resourceVersion = rv
 			updated = true
 		}
 
 		if updated && deploymentComplete(nova, &nova.Status) {
-			return nil
+			return true, nil
+		}
+
+		if exceeded, message := progressDeadlineExceeded(nova); updated && exceeded {
+			err := fmt.Errorf("rollout exceeded its progress deadline: %s", message)
+			if diag, _ := diagnoseDeployment(d.client, nova, d.logs); diag != nil {
+				err = fmt.Errorf("%s; %s", err, diag.message)
+			}
+			return true, err
 		}
 
 		logger.WithFields(log.Fields{
@@ -68,14 +84,66 @@ func (d deployment) Watch(logger *log.Entry, resource unstructured.Unstructured,
 			"deployment_observed_generation": nova.Status.ObservedGeneration,
 		}).Tracef("Still waiting for deployment to finish rollout...")
 
-		time.Sleep(requestInterval)
+		if updated && nova.Spec.Replicas != nil {
+			reportProgress(ctx, "%d/%d replicas updated, %d available", nova.Status.UpdatedReplicas, *nova.Spec.Replicas, nova.Status.AvailableReplicas)
+		}
+
+		return false, nil
 	}
 
-	if err != nil {
-		return fmt.Errorf("%s; last error was: %s", ErrDeploymentTimeout, err)
+	// Pod failures are not reflected in the deployment status, so they are looked for separately.
+	// A fatal diagnosis aborts monitoring right away, instead of waiting for the deadline.
+	diagnoseCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lock sync.Mutex
+	var diag *diagnosis
+
+	go func() {
+		for {
+			select {
+			case <-diagnoseCtx.Done():
+				return
+			case <-time.After(diagnoseInterval):
+			}
+
+			deploy, err := client.Get(resource.GetName(), metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+
+			found, err := diagnoseDeployment(d.client, deploy, d.logs)
+			if err != nil {
+				logger.Tracef("Diagnosing pods: %s", err)
+				continue
+			}
+
+			lock.Lock()
+			diag = found
+			lock.Unlock()
+
+			if found != nil && found.fatal {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = watchUntil(diagnoseCtx, logger, resource.GetName(), get, client.Watch, deadline, check)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	switch {
+	case err == nil || diag == nil || ctx.Err() != nil:
+		return err
+	case diag.fatal:
+		return fmt.Errorf("%s", diag.message)
+	case err == ErrDeploymentTimeout:
+		return fmt.Errorf("%s; %s", err, diag.message)
 	}
 
-	return ErrDeploymentTimeout
+	return err
 }
 
 // deploymentComplete considers a deployment to be complete once all of its desired replicas
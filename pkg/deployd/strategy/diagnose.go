@@ -0,0 +1,208 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+var (
+	diagnoseInterval = time.Second * 15
+
+	// Pods that crash this many times are not expected to recover by themselves.
+	crashLoopRestarts int32 = 3
+
+	// Pods may be unschedulable for a while until the cluster has scaled up.
+	unschedulableGrace = time.Minute * 5
+
+	// Number of log lines from a crashed container to include in the diagnosis.
+	diagnosisLogLines int64 = 5
+
+	// Containers waiting for these reasons will not start without a change to the deployment.
+	fatalWaitingReasons = map[string]bool{
+		"CrashLoopBackOff":           true,
+		"ImagePullBackOff":           true,
+		"InvalidImageName":           true,
+		"CreateContainerConfigError": true,
+	}
+)
+
+// Retrieves the last few log lines of the previous run of a container.
+// Diagnoses end up in deployment statuses synchronized to GitHub, so logs are only included when explicitly enabled.
+type logFunc func(client kubernetes.Interface, pod *v1.Pod, container string) string
+
+// A diagnosis explains why the pods of a rollout are not becoming ready.
+// Fatal diagnoses describe problems that will not go away without intervention.
+type diagnosis struct {
+	fatal   bool
+	message string
+}
+
+// Find the replica set of the current revision of a deployment.
+func newReplicaSet(client kubernetes.Interface, deploy *apps.Deployment) (*apps.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	replicaSets, err := client.AppsV1().ReplicaSets(deploy.GetNamespace()).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revision := deploy.GetAnnotations()[revisionAnnotation]
+	for i, rs := range replicaSets.Items {
+		if metav1.IsControlledBy(&rs, deploy) && rs.GetAnnotations()[revisionAnnotation] == revision {
+			return &replicaSets.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Inspect the pods of the current revision of a deployment, and explain why they are not ready.
+// Container logs are only included if a log function is given. Returns nil if no problems are found.
+func diagnoseDeployment(client kubernetes.Interface, deploy *apps.Deployment, logs logFunc) (*diagnosis, error) {
+	rs, err := newReplicaSet(client, deploy)
+	if err != nil || rs == nil {
+		return nil, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(rs.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := client.CoreV1().Pods(rs.GetNamespace()).List(metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var found *diagnosis
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !metav1.IsControlledBy(pod, rs) {
+			continue
+		}
+		diag := diagnosePod(client, pod, logs)
+		if diag != nil && (found == nil || diag.fatal && !found.fatal) {
+			found = diag
+		}
+	}
+
+	return found, nil
+}
+
+// Explain why a single pod is not running, if there is anything to explain.
+func diagnosePod(client kubernetes.Interface, pod *v1.Pod, logs logFunc) *diagnosis {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+			return &diagnosis{
+				fatal:   time.Since(condition.LastTransitionTime.Time) > unschedulableGrace,
+				message: fmt.Sprintf("pod '%s' cannot be scheduled: %s", pod.GetName(), condition.Message),
+			}
+		}
+	}
+
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+
+	for _, status := range statuses {
+		waiting := status.State.Waiting
+		if waiting == nil || len(waiting.Reason) == 0 || waiting.Reason == "ContainerCreating" || waiting.Reason == "PodInitializing" {
+			continue
+		}
+
+		message := waiting.Message
+		if len(message) == 0 {
+			message = lastWarning(client, pod)
+		}
+
+		diag := &diagnosis{
+			fatal:   fatalWaitingReasons[waiting.Reason],
+			message: fmt.Sprintf("container '%s' in pod '%s': %s", status.Name, pod.GetName(), waiting.Reason),
+		}
+
+		if waiting.Reason == "CrashLoopBackOff" {
+			diag.fatal = status.RestartCount >= crashLoopRestarts
+			if terminated := status.LastTerminationState.Terminated; terminated != nil {
+				message = fmt.Sprintf("last exit code %d (%s)", terminated.ExitCode, terminated.Reason)
+			}
+			if logs != nil {
+				if lines := logs(client, pod, status.Name); len(lines) > 0 {
+					message = fmt.Sprintf("%s; last log lines: %s", message, lines)
+				}
+			}
+		}
+
+		if len(message) > 0 {
+			diag.message = fmt.Sprintf("%s: %s", diag.message, message)
+		}
+
+		return diag
+	}
+
+	return nil
+}
+
+// Return the message of the most recent warning event for a pod, if any.
+func lastWarning(client kubernetes.Interface, pod *v1.Pod) string {
+	events, err := client.CoreV1().Events(pod.GetNamespace()).List(metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.name": pod.GetName(),
+			"involvedObject.kind": "Pod",
+			"type":                v1.EventTypeWarning,
+		}.String(),
+	})
+	if err != nil || len(events.Items) == 0 {
+		return ""
+	}
+
+	sort.Slice(events.Items, func(i, j int) bool {
+		return events.Items[i].LastTimestamp.Before(&events.Items[j].LastTimestamp)
+	})
+
+	return events.Items[len(events.Items)-1].Message
+}
+
+// Return the last few log lines of the previous run of a container, joined on a single line.
+func lastLogLines(client kubernetes.Interface, pod *v1.Pod, container string) string {
+	data, err := client.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), &v1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &diagnosisLogLines,
+	}).DoRaw()
+	if err != nil {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	return strings.Join(lines, " | ")
+}
+
+// Returns true if the deployment controller has given up on the current rollout.
+func progressDeadlineExceeded(deploy *apps.Deployment) (bool, string) {
+	if deploy.Status.ObservedGeneration < deploy.Generation {
+		return false, ""
+	}
+	for _, condition := range deploy.Status.Conditions {
+		if condition.Type == apps.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return true, condition.Message
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDiagnosePod(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	logs := func(_ kubernetes.Interface, _ *v1.Pod, container string) string {
+		return container + " crashed | exiting"
+	}
+
+	pod := func(status v1.PodStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-1234", Namespace: "aura"},
+			Status:     status,
+		}
+	}
+	waiting := func(reason, message string, restarts int32) v1.PodStatus {
+		return v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: restarts,
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{Reason: reason, Message: message},
+					},
+					LastTerminationState: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+					},
+				},
+			},
+		}
+	}
+	unschedulable := func(since time.Time) v1.PodStatus {
+		return v1.PodStatus{
+			Conditions: []v1.PodCondition{
+				{
+					Type:               v1.PodScheduled,
+					Status:             v1.ConditionFalse,
+					Reason:             v1.PodReasonUnschedulable,
+					Message:            "0/3 nodes are available: 3 Insufficient memory.",
+					LastTransitionTime: metav1.NewTime(since),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		diagnosis *diagnosis
+	}{
+		{
+			name: "running",
+			pod:  pod(v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{Name: "app", State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}}),
+		},
+		{
+			name: "creating",
+			pod:  pod(waiting("ContainerCreating", "", 0)),
+		},
+		{
+			name:      "image pull",
+			pod:       pod(waiting("ImagePullBackOff", "Back-off pulling image \"app:nonexistent\"", 0)),
+			diagnosis: &diagnosis{fatal: true, message: "container 'app' in pod 'app-1234': ImagePullBackOff: Back-off pulling image \"app:nonexistent\""},
+		},
+		{
+			name:      "first crash",
+			pod:       pod(waiting("CrashLoopBackOff", "back-off 10s restarting failed container", 1)),
+			diagnosis: &diagnosis{fatal: false, message: "container 'app' in pod 'app-1234': CrashLoopBackOff: last exit code 1 (Error); last log lines: app crashed | exiting"},
+		},
+		{
+			name:      "crash loop",
+			pod:       pod(waiting("CrashLoopBackOff", "back-off 40s restarting failed container", 3)),
+			diagnosis: &diagnosis{fatal: true, message: "container 'app' in pod 'app-1234': CrashLoopBackOff: last exit code 1 (Error); last log lines: app crashed | exiting"},
+		},
+		{
+			name:      "recently unschedulable",
+			pod:       pod(unschedulable(time.Now())),
+			diagnosis: &diagnosis{fatal: false, message: "pod 'app-1234' cannot be scheduled: 0/3 nodes are available: 3 Insufficient memory."},
+		},
+		{
+			name:      "unschedulable",
+			pod:       pod(unschedulable(time.Now().Add(-unschedulableGrace * 2))),
+			diagnosis: &diagnosis{fatal: true, message: "pod 'app-1234' cannot be scheduled: 0/3 nodes are available: 3 Insufficient memory."},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.diagnosis, diagnosePod(client, test.pod, logs), test.name)
+	}
+}
+
+func TestDiagnosePodWithoutLogs(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-1234", Namespace: "aura"},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:         "app",
+					RestartCount: 3,
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					},
+					LastTerminationState: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+					},
+				},
+			},
+		},
+	}
+
+	expected := &diagnosis{fatal: true, message: "container 'app' in pod 'app-1234': CrashLoopBackOff: last exit code 1 (Error)"}
+	assert.Equal(t, expected, diagnosePod(fake.NewSimpleClientset(), pod, nil))
+}
+
+func TestWatchStrategyDiagnosisLogs(t *testing.T) {
+	gvk := apps.SchemeGroupVersion.WithKind("Deployment")
+	client := fake.NewSimpleClientset()
+
+	assert.Nil(t, NewWatchStrategy(gvk, client, nil, false).(deployment).logs)
+	assert.NotNil(t, NewWatchStrategy(gvk, client, nil, true).(deployment).logs)
+}
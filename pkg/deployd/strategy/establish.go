@@ -0,0 +1,81 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+var (
+	ErrEstablishTimeout = fmt.Errorf("timeout while waiting for resource to be established")
+)
+
+// Reports whether a live resource is ready to be depended upon by other resources.
+type establishedFunc func(resource unstructured.Unstructured) bool
+
+// A namespace can hold resources once it is active.
+func namespaceEstablished(resource unstructured.Unstructured) bool {
+	phase, _, _ := unstructured.NestedString(resource.Object, "status", "phase")
+	return phase == "Active"
+}
+
+// Custom resources can be created once their definition is established.
+func definitionEstablished(resource unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(resource.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+func establishedCheck(resource unstructured.Unstructured) establishedFunc {
+	gvk := resource.GroupVersionKind()
+
+	if gvk.Group == "" && gvk.Kind == "Namespace" {
+		return namespaceEstablished
+	}
+
+	if gvk.Group == "apiextensions.k8s.io" && gvk.Kind == "CustomResourceDefinition" {
+		return definitionEstablished
+	}
+
+	return nil
+}
+
+// WaitForEstablished returns nil when the resource is ready to be used by resources deployed after it,
+// or error if it has not become ready within the specified deadline, or the context is cancelled.
+//
+// Resources that are usable as soon as they are persisted are considered established right away.
+func WaitForEstablished(ctx context.Context, logger *log.Entry, client dynamic.ResourceInterface, resource unstructured.Unstructured, deadline time.Time) error {
+	established := establishedCheck(resource)
+	if established == nil {
+		return nil
+	}
+
+	get := func() (runtime.Object, error) {
+		return client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	check := func(obj runtime.Object) (bool, error) {
+		live, ok := obj.(*unstructured.Unstructured)
+		return ok && established(*live), nil
+	}
+
+	err := watchUntil(ctx, logger, resource.GetName(), get, client.Watch, deadline, check)
+	if err == ErrDeploymentTimeout {
+		return ErrEstablishTimeout
+	}
+	return err
+}
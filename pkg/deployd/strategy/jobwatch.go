@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	v1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -15,37 +17,34 @@ type job struct {
 	client kubernetes.Interface
 }
 
-func (j job) Watch(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
-	var job *v1.Job
-	var err error
-
+func (j job) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
 	client := j.client.BatchV1().Jobs(resource.GetNamespace())
 
-	// Wait until the new job object is present in the cluster.
-	for deadline.After(time.Now()) {
-		job, err = client.Get(resource.GetName(), metav1.GetOptions{})
+	get := func() (runtime.Object, error) {
+		return client.Get(resource.GetName(), metav1.GetOptions{})
+	}
 
-		if err != nil {
-			time.Sleep(requestInterval)
-			continue
+	// Wait until the new job object is present in the cluster, and has either completed or failed.
+	check := func(obj runtime.Object) (bool, error) {
+		job, ok := obj.(*v1.Job)
+		if !ok {
+			return false, nil
 		}
 
 		if jobComplete(job) {
-			return nil
+			return true, nil
 		}
 
 		if status, condition := jobFailed(job); status {
-			return fmt.Errorf("job failed: %s", condition.String())
+			return true, fmt.Errorf("job failed: %s", condition.String())
 		}
 
 		logger.Tracef("Still waiting for job to complete...")
-		time.Sleep(requestInterval)
+		reportProgress(ctx, "%d pods active, %d succeeded, %d failed", job.Status.Active, job.Status.Succeeded, job.Status.Failed)
+		return false, nil
 	}
 
-	if err != nil {
-		return fmt.Errorf("%s; last error was: %s", ErrDeploymentTimeout, err)
-	}
-	return ErrDeploymentTimeout
+	return watchUntil(ctx, logger, resource.GetName(), get, client.Watch, deadline, check)
 }
 
 func jobComplete(job *v1.Job) bool {
@@ -0,0 +1,24 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProgressFunc receives human readable descriptions of how far a rollout has come.
+type ProgressFunc func(message string)
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes watch strategies report rollout progress to the given function.
+func WithProgress(ctx context.Context, progress ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// Report rollout progress, if anyone is listening.
+func reportProgress(ctx context.Context, format string, args ...interface{}) {
+	progress, ok := ctx.Value(progressKey{}).(ProgressFunc)
+	if ok && progress != nil {
+		progress(fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,78 @@
+package strategy
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+type statefulSet struct {
+	client kubernetes.Interface
+}
+
+func (s statefulSet) Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error {
+	client := s.client.AppsV1().StatefulSets(resource.GetNamespace())
+
+	get := func() (runtime.Object, error) {
+		return client.Get(resource.GetName(), metav1.GetOptions{})
+	}
+
+	check := func(obj runtime.Object) (bool, error) {
+		set, ok := obj.(*apps.StatefulSet)
+		if !ok {
+			return false, nil
+		}
+
+		if statefulSetComplete(set) {
+			return true, nil
+		}
+
+		logger.WithFields(log.Fields{
+			"statefulset_replicas":            set.Status.Replicas,
+			"statefulset_ready_replicas":      set.Status.ReadyReplicas,
+			"statefulset_updated_replicas":    set.Status.UpdatedReplicas,
+			"statefulset_observed_generation": set.Status.ObservedGeneration,
+		}).Tracef("Still waiting for stateful set to finish rollout...")
+
+		if set.Spec.Replicas != nil {
+			reportProgress(ctx, "%d/%d replicas updated, %d ready", set.Status.UpdatedReplicas, *set.Spec.Replicas, set.Status.ReadyReplicas)
+		}
+
+		return false, nil
+	}
+
+	return watchUntil(ctx, logger, resource.GetName(), get, client.Watch, deadline, check)
+}
+
+// statefulSetComplete considers a stateful set to be complete once the controller has observed the latest generation
+// and all replicas are ready. With a rolling update, all pods from the partition and up must be updated.
+// With the OnDelete strategy, pods are only replaced when deleted by the user, so updates are not waited for.
+//
+// Adapted from
+// https://github.com/kubernetes/kubernetes/blob/v1.15.0/pkg/kubectl/rollout_status.go#L120
+func statefulSetComplete(set *apps.StatefulSet) bool {
+	if set.Status.ObservedGeneration < set.Generation {
+		return false
+	}
+
+	if set.Spec.Replicas != nil && set.Status.ReadyReplicas < *set.Spec.Replicas {
+		return false
+	}
+
+	if set.Spec.UpdateStrategy.Type != apps.RollingUpdateStatefulSetStrategyType {
+		return true
+	}
+
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0 && set.Spec.Replicas != nil {
+		return set.Status.UpdatedReplicas >= *set.Spec.Replicas-*rollingUpdate.Partition
+	}
+
+	return set.Status.UpdateRevision == set.Status.CurrentRevision
+}
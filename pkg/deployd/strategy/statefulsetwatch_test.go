@@ -0,0 +1,51 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func int32p(i int32) *int32 {
+	return &i
+}
+
+func TestStatefulSetComplete(t *testing.T) {
+	set := func(strategy apps.StatefulSetUpdateStrategy, status apps.StatefulSetStatus) *apps.StatefulSet {
+		return &apps.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Generation: 2},
+			Spec: apps.StatefulSetSpec{
+				Replicas:       int32p(3),
+				UpdateStrategy: strategy,
+			},
+			Status: status,
+		}
+	}
+
+	rolling := apps.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType}
+	partitioned := apps.StatefulSetUpdateStrategy{
+		Type:          apps.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateStatefulSetStrategy{Partition: int32p(2)},
+	}
+	onDelete := apps.StatefulSetUpdateStrategy{Type: apps.OnDeleteStatefulSetStrategyType}
+
+	tests := []struct {
+		name     string
+		set      *apps.StatefulSet
+		complete bool
+	}{
+		{"generation not observed", set(rolling, apps.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 3}), false},
+		{"replicas not ready", set(rolling, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 2}), false},
+		{"revision not updated", set(rolling, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "a", UpdateRevision: "b"}), false},
+		{"rolled out", set(rolling, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "b", UpdateRevision: "b"}), true},
+		{"partition not updated", set(partitioned, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 0}), false},
+		{"partition updated", set(partitioned, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentRevision: "a", UpdateRevision: "b"}), true},
+		{"on delete", set(onDelete, apps.StatefulSetStatus{ObservedGeneration: 2, ReadyReplicas: 3, CurrentRevision: "a", UpdateRevision: "b"}), true},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.complete, statefulSetComplete(test.set), test.name)
+	}
+}
@@ -1,6 +1,7 @@
 package strategy
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -12,29 +13,54 @@ import (
 )
 
 var (
-	requestInterval      = time.Second * 5
-	ErrDeploymentTimeout = fmt.Errorf("timeout while waiting for deployment to succeed")
+	requestInterval        = time.Second * 5
+	ErrDeploymentTimeout   = fmt.Errorf("timeout while waiting for deployment to succeed")
+	ErrDeploymentCancelled = fmt.Errorf("deployment was cancelled")
 )
 
 type WatchStrategy interface {
-	Watch(logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error
+	Watch(ctx context.Context, logger *log.Entry, resource unstructured.Unstructured, deadline time.Time) error
 }
 
 type NoOp struct {
 }
 
-func (c NoOp) Watch(logger *log.Entry, resource unstructured.Unstructured, _ time.Time) error {
+func (c NoOp) Watch(_ context.Context, logger *log.Entry, resource unstructured.Unstructured, _ time.Time) error {
 	logger.Infof("Watch not implemented for resource %s/%s", resource.GroupVersionKind().String(), resource.GetName())
 	return nil
 }
 
-func NewWatchStrategy(gvk schema.GroupVersionKind, structuredClient kubernetes.Interface, unstructuredClient dynamic.Interface) WatchStrategy {
+// Wait between requests to the cluster. Returns ErrDeploymentCancelled if the context is cancelled in the meantime.
+func sleep(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ErrDeploymentCancelled
+	case <-time.After(requestInterval):
+		return nil
+	}
+}
+
+// NewWatchStrategy returns the strategy used to monitor the rollout of a resource.
+// With diagnosisLogs, the last log lines of crashing containers are included when a rollout fails.
+func NewWatchStrategy(gvk schema.GroupVersionKind, structuredClient kubernetes.Interface, unstructuredClient dynamic.Interface, diagnosisLogs bool) WatchStrategy {
 	if gvk.Group == "nais.io" && gvk.Kind == "Application" {
 		return application{unstructuredClient: unstructuredClient, structuredClient: structuredClient}
 	}
 
 	if gvk.Kind == "Deployment" && (gvk.Group == "apps" || gvk.Group == "extensions") {
-		return deployment{client: structuredClient}
+		deploy := deployment{client: structuredClient}
+		if diagnosisLogs {
+			deploy.logs = lastLogLines
+		}
+		return deploy
+	}
+
+	if gvk.Group == "apps" && gvk.Kind == "StatefulSet" {
+		return statefulSet{client: structuredClient}
+	}
+
+	if gvk.Group == "apps" && gvk.Kind == "DaemonSet" {
+		return daemonSet{client: structuredClient}
 	}
 
 	if gvk.Group == "batch" && gvk.Kind == "Job" && gvk.Version == "v1" {
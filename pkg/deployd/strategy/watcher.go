@@ -0,0 +1,157 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Retrieves the current version of the watched resource.
+type getFunc func() (runtime.Object, error)
+
+// Opens a watch on the resource type, filtered by the list options.
+type watchFunc func(opts metav1.ListOptions) (watch.Interface, error)
+
+// Inspects a version of the watched resource. Returns true if the rollout is finished,
+// along with an error if it finished unsuccessfully.
+type checkFunc func(obj runtime.Object) (bool, error)
+
+// The watch API failed in a way that cannot be recovered by resuming the watch.
+type watchError struct {
+	err error
+}
+
+func (e watchError) Error() string {
+	return e.err.Error()
+}
+
+// Follow a single resource until check reports that its rollout is finished, or the deadline is reached.
+//
+// Changes are received through the Kubernetes watch API, resuming from the last seen resource version
+// whenever the API server closes the connection. Bookmarks are requested so that resuming stays cheap.
+// If the watch cannot be established, or the API server reports an error, the resource is polled instead.
+func watchUntil(ctx context.Context, logger *log.Entry, name string, get getFunc, watchResource watchFunc, deadline time.Time, check checkFunc) error {
+	var resourceVersion string
+
+	obj, err := get()
+	if err == nil {
+		if done, err := check(obj); done {
+			return err
+		}
+		if accessor, err := meta.Accessor(obj); err == nil {
+			resourceVersion = accessor.GetResourceVersion()
+		}
+	} else if !errors.IsNotFound(err) {
+		logger.Tracef("Retrieving %s: %s", name, err)
+	}
+
+	for deadline.After(time.Now()) {
+		timeout := int64(time.Until(deadline).Seconds()) + 1
+		watcher, err := watchResource(metav1.ListOptions{
+			FieldSelector:       fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion:     resourceVersion,
+			AllowWatchBookmarks: true,
+			TimeoutSeconds:      &timeout,
+		})
+		if err != nil {
+			logger.Warnf("Unable to watch %s, falling back to polling: %s", name, err)
+			return pollUntil(ctx, logger, get, deadline, check)
+		}
+
+		var done bool
+		resourceVersion, done, err = follow(ctx, watcher, resourceVersion, deadline, check)
+		werr, failed := err.(watchError)
+		switch {
+		case failed && (errors.IsGone(werr.err) || errors.IsResourceExpired(werr.err)):
+			logger.Tracef("Watch on %s expired; restarting from the current version", name)
+			resourceVersion = ""
+			continue
+		case failed:
+			logger.Warnf("Watch on %s failed, falling back to polling: %s", name, err)
+			return pollUntil(ctx, logger, get, deadline, check)
+		case done:
+			return err
+		}
+
+		logger.Tracef("Watch on %s closed by API server; resuming from version %s", name, resourceVersion)
+	}
+
+	return ErrDeploymentTimeout
+}
+
+// Consume events from a watch until the rollout is finished, or the watch is closed.
+// Returns the last seen resource version, and whether or not watching is done.
+func follow(ctx context.Context, watcher watch.Interface, resourceVersion string, deadline time.Time, check checkFunc) (string, bool, error) {
+	defer watcher.Stop()
+
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, true, ErrDeploymentCancelled
+
+		case <-timeout.C:
+			return resourceVersion, true, ErrDeploymentTimeout
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, false, nil
+			}
+
+			if event.Type == watch.Error {
+				return resourceVersion, false, watchError{err: errors.FromObject(event.Object)}
+			}
+
+			accessor, err := meta.Accessor(event.Object)
+			if err != nil {
+				return resourceVersion, false, watchError{err: fmt.Errorf("unexpected object in watch: %s", err)}
+			}
+			resourceVersion = accessor.GetResourceVersion()
+
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+
+			if done, err := check(event.Object); done {
+				return resourceVersion, true, err
+			}
+		}
+	}
+}
+
+// Retrieve the resource at a fixed interval until check reports that its rollout is finished, or the deadline is reached.
+func pollUntil(ctx context.Context, logger *log.Entry, get getFunc, deadline time.Time, check checkFunc) error {
+	var err error
+	var obj runtime.Object
+
+	for deadline.After(time.Now()) {
+		obj, err = get()
+		if err == nil {
+			if done, err := check(obj); done {
+				return err
+			}
+		} else {
+			logger.Tracef("Recoverable error while polling: %s", err)
+		}
+
+		if err := sleep(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s; last error was: %s", ErrDeploymentTimeout, err)
+	}
+
+	return ErrDeploymentTimeout
+}
@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func configMap(resourceVersion, value string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo",
+			ResourceVersion: resourceVersion,
+		},
+		Data: map[string]string{"state": value},
+	}
+}
+
+func checkState(obj runtime.Object) (bool, error) {
+	switch obj.(*v1.ConfigMap).Data["state"] {
+	case "done":
+		return true, nil
+	case "failed":
+		return true, fmt.Errorf("failed")
+	}
+	return false, nil
+}
+
+func TestWatchUntil(t *testing.T) {
+	logger := log.NewEntry(log.StandardLogger())
+	deadline := time.Now().Add(time.Second * 5)
+
+	get := func() (runtime.Object, error) {
+		return configMap("1", "pending"), nil
+	}
+
+	t.Run("finishes on watch event", func(t *testing.T) {
+		var options metav1.ListOptions
+		watcher := watch.NewFake()
+		watchResource := func(opts metav1.ListOptions) (watch.Interface, error) {
+			options = opts
+			return watcher, nil
+		}
+
+		go func() {
+			watcher.Action(watch.Bookmark, configMap("2", ""))
+			watcher.Modify(configMap("3", "pending"))
+			watcher.Modify(configMap("4", "failed"))
+		}()
+
+		err := watchUntil(context.Background(), logger, "foo", get, watchResource, deadline, checkState)
+		assert.EqualError(t, err, "failed")
+		assert.Equal(t, "1", options.ResourceVersion)
+		assert.Equal(t, "metadata.name=foo", options.FieldSelector)
+		assert.True(t, options.AllowWatchBookmarks)
+	})
+
+	t.Run("resumes closed watch from last version", func(t *testing.T) {
+		versions := make([]string, 0)
+		watchResource := func(opts metav1.ListOptions) (watch.Interface, error) {
+			versions = append(versions, opts.ResourceVersion)
+			watcher := watch.NewFake()
+			go func() {
+				if len(versions) == 1 {
+					watcher.Action(watch.Bookmark, configMap("2", ""))
+					watcher.Stop()
+				} else {
+					watcher.Modify(configMap("3", "done"))
+				}
+			}()
+			return watcher, nil
+		}
+
+		err := watchUntil(context.Background(), logger, "foo", get, watchResource, deadline, checkState)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2"}, versions)
+	})
+
+	t.Run("falls back to polling", func(t *testing.T) {
+		watchResource := func(opts metav1.ListOptions) (watch.Interface, error) {
+			return nil, errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "foo", fmt.Errorf("no watch"))
+		}
+		polls := 0
+		get := func() (runtime.Object, error) {
+			polls++
+			if polls > 1 {
+				return configMap("2", "done"), nil
+			}
+			return configMap("1", "pending"), nil
+		}
+
+		defer func(interval time.Duration) { requestInterval = interval }(requestInterval)
+		requestInterval = time.Millisecond
+
+		err := watchUntil(context.Background(), logger, "foo", get, watchResource, deadline, checkState)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, polls)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		watchResource := func(opts metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := watchUntil(ctx, logger, "foo", get, watchResource, deadline, checkState)
+		assert.Equal(t, ErrDeploymentCancelled, err)
+	})
+}
@@ -0,0 +1,125 @@
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/api/v1/deployments"
+	log "github.com/sirupsen/logrus"
+)
+
+// Cancel aborts an existing deployment that has not yet reached its final state,
+// identified the same way as in Status.
+func (d *Deployer) Cancel(cfg Config, deploymentID string) (ExitCode, error) {
+	setupLogging(cfg.Actions, cfg.Quiet)
+
+	if err := validateQuery(cfg, deploymentID); err != nil {
+		return ExitInvocationFailure, err
+	}
+
+	decoded, err := hex.DecodeString(cfg.APIKey)
+	if err != nil {
+		return ExitInvocationFailure, fmt.Errorf("%s: %s", MalformedAPIKeyMsg, err)
+	}
+
+	targetURL, err := url.Parse(d.DeployServer)
+	if err != nil {
+		return ExitInvocationFailure, fmt.Errorf("%s: %s", MalformedURLMsg, err)
+	}
+
+	if len(deploymentID) == 0 {
+		_, code, response, err := check(deploymentID, decoded, *targetURL, cfg)
+		if err != nil {
+			return code, err
+		}
+		if response == nil || len(response.DeploymentID) == 0 {
+			return ExitNoDeployment, fmt.Errorf("deployment not found")
+		}
+		deploymentID = response.DeploymentID
+	}
+
+	return d.cancel(deploymentID, decoded, *targetURL, cfg)
+}
+
+// Request cancellation of a deployment.
+func (d *Deployer) cancel(deploymentID string, key []byte, targetURL url.URL, cfg Config) (ExitCode, error) {
+	cancelReq := &api_v1_deployments.CancelRequest{
+		DeploymentID: deploymentID,
+		Team:         cfg.Team,
+		Timestamp:    api_v1.Timestamp(time.Now().Unix()),
+	}
+
+	payload, err := json.Marshal(cancelReq)
+	if err != nil {
+		return ExitInternalError, fmt.Errorf("unable to marshal cancel request: %s", err)
+	}
+
+	targetURL.Path = fmt.Sprintf(CancelAPIPath, url.PathEscape(deploymentID))
+	req, err := http.NewRequest(http.MethodPost, targetURL.String(), bytes.NewBuffer(payload))
+	if err != nil {
+		return ExitInternalError, fmt.Errorf("internal error creating http request: %v", err)
+	}
+
+	req.Header.Add("content-type", "application/json")
+	req.Header.Add(api_v1.SignatureHeader, sign(payload, key))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return ExitUnavailable, fmt.Errorf("error making request: %s", err)
+	}
+	defer resp.Body.Close()
+
+	response := &api_v1_deployments.CancelResponse{}
+	err = json.NewDecoder(resp.Body).Decode(response)
+	if err != nil {
+		return ExitInternalError, fmt.Errorf("received invalid response from server: %s: %s", resp.Status, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		log.Infof("deployment: %s: %s", deploymentID, response.Message)
+		return ExitSuccess, nil
+	case http.StatusNotFound:
+		return ExitNoDeployment, fmt.Errorf("%s", response.Message)
+	case http.StatusServiceUnavailable:
+		return ExitUnavailable, fmt.Errorf("%s", response.Message)
+	default:
+		return ExitInternalError, fmt.Errorf("cancel deployment: %s: %s", resp.Status, response.Message)
+	}
+}
+
+// Cancel the deployment if the program is interrupted while waiting for the deployment to finish.
+// The returned channel is closed when this happens, and stopWaiting is called to abort any status requests.
+func (d *Deployer) cancelOnInterrupt(ctx context.Context, stopWaiting context.CancelFunc, deploymentID string, key []byte, targetURL url.URL, cfg Config) <-chan struct{} {
+	interrupts := make(chan os.Signal, 1)
+	interrupted := make(chan struct{})
+
+	signal.Notify(interrupts, os.Interrupt)
+
+	go func() {
+		defer signal.Stop(interrupts)
+
+		select {
+		case <-interrupts:
+			log.Warnf("Interrupted; cancelling deployment %s", deploymentID)
+			_, err := d.cancel(deploymentID, key, targetURL, cfg)
+			if err != nil {
+				log.Errorf("Unable to cancel deployment: %s", err)
+			}
+			close(interrupted)
+			stopWaiting()
+		case <-ctx.Done():
+		}
+	}()
+
+	return interrupted
+}
@@ -15,18 +15,24 @@ type Config struct {
 	APIKey          string
 	DeployServerURL string
 	Cluster         string
+	Deployer        string
 	Environment     string
+	Inventory       string
 	PrintPayload    bool
 	DryRun          bool
 	Owner           string
 	PollInterval    time.Duration
+	Prune           bool
 	Quiet           bool
 	Ref             string
+	Remote          bool
 	Repository      string
 	Resource        []string
 	Retry           bool
+	Rollback        bool
 	Team            string
 	Timeout         time.Duration
+	TimeoutSet      bool
 	Variables       []string
 	VariablesFile   string
 	Wait            bool
@@ -35,32 +41,44 @@ type Config struct {
 var cfg Config
 
 func init() {
-	flag.ErrHelp = fmt.Errorf("\ndeploy prepares and submits Kubernetes resources to a NAIS cluster.\n")
+	flag.ErrHelp = fmt.Errorf("\ndeploy prepares and submits Kubernetes resources to a NAIS cluster.\n" +
+		"\nUse 'deploy status [CORRELATION_ID]' or 'deploy history [CORRELATION_ID]' to look up an existing deployment.\n" +
+		"Use 'deploy cancel [CORRELATION_ID]' to abort a deployment that has not yet finished.\n" +
+		"Without a correlation ID, the most recent deployment from --owner/--repository is used.\n")
 
 	flag.BoolVar(&cfg.Actions, "actions", getEnvBool("ACTIONS", false), "Use GitHub Actions compatible error and warning messages. (env ACTIONS)")
 	flag.StringVar(&cfg.APIKey, "apikey", os.Getenv("APIKEY"), "NAIS Deploy API key. (env APIKEY)")
 	flag.StringVar(&cfg.Cluster, "cluster", os.Getenv("CLUSTER"), "NAIS cluster to deploy into. (env CLUSTER)")
+	flag.StringVar(&cfg.Deployer, "deployer", getEnv("DEPLOYER", os.Getenv("GITHUB_ACTOR")), "Person or system making the deployment, recorded for auditing. Defaults to the GitHub Actions actor. (env DEPLOYER)")
 	flag.StringVar(&cfg.DeployServerURL, "deploy-server", getEnv("DEPLOY_SERVER", DefaultDeployServer), "URL to API server. (env DEPLOY_SERVER)")
-	flag.BoolVar(&cfg.DryRun, "dry-run", getEnvBool("DRY_RUN", false), "Run templating, but don't actually make any requests. (env DRY_RUN)")
+	flag.BoolVar(&cfg.DryRun, "dry-run", getEnvBool("DRY_RUN", false), "Run templating, but don't actually make any requests, unless --remote is given. (env DRY_RUN)")
 	flag.StringVar(&cfg.Environment, "environment", os.Getenv("ENVIRONMENT"), "Environment for GitHub deployment. Autodetected from nais.yaml if not specified. (env ENVIRONMENT)")
+	flag.StringVar(&cfg.Inventory, "inventory", os.Getenv("INVENTORY"), "Name of the set of resources managed by this deployment, used for pruning. Defaults to the repository name, but is required with --prune. (env INVENTORY)")
 	flag.StringVar(&cfg.Owner, "owner", getEnv("OWNER", DefaultOwner), "Owner of GitHub repository. (env OWNER)")
 	flag.BoolVar(&cfg.PrintPayload, "print-payload", getEnvBool("PRINT_PAYLOAD", false), "Print templated resources to standard output. (env PRINT_PAYLOAD)")
 	flag.BoolVar(&cfg.Quiet, "quiet", getEnvBool("QUIET", false), "Suppress printing of informational messages except errors. (env QUIET)")
+	flag.BoolVar(&cfg.Prune, "prune", getEnvBool("PRUNE", false), "Delete resources from the previous deployment of this inventory that are no longer part of the deployment. Requires --inventory. (env PRUNE)")
 	flag.StringVar(&cfg.Ref, "ref", getEnv("REF", DefaultRef), "Git commit hash, tag, or branch of the code being deployed. (env REF)")
+	flag.BoolVar(&cfg.Remote, "remote", getEnvBool("REMOTE", false), "With --dry-run, validate resources in the cluster without applying them, and print a diff against the live resources. (env REMOTE)")
 	flag.StringVar(&cfg.Repository, "repository", os.Getenv("REPOSITORY"), "Name of GitHub repository. (env REPOSITORY)")
-	flag.StringSliceVar(&cfg.Resource, "resource", getEnvStringSlice("RESOURCE"), "File with Kubernetes resource. Can be specified multiple times. (env RESOURCE)")
+	flag.StringSliceVar(&cfg.Resource, "resource", getEnvStringSlice("RESOURCE"), "File, directory or glob pattern with Kubernetes resources. Files can contain multiple YAML documents. Can be specified multiple times. (env RESOURCE)")
 	flag.BoolVar(&cfg.Retry, "retry", getEnvBool("RETRY", true), "Retry deploy when encountering transient errors. (env RETRY)")
+	flag.BoolVar(&cfg.Rollback, "rollback", getEnvBool("ROLLBACK", false), "Restore the previous version of all resources if the deployment fails. (env ROLLBACK)")
 	flag.StringVar(&cfg.Team, "team", os.Getenv("TEAM"), "Team making the deployment. Auto-detected from nais.yaml if possible. (env TEAM)")
-	flag.DurationVar(&cfg.Timeout, "timeout", getEnvDuration("TIMEOUT", DefaultDeployTimeout), "Time to wait for successful deployment. (env TIMEOUT)")
+	flag.DurationVar(&cfg.Timeout, "timeout", getEnvDuration("TIMEOUT", DefaultDeployTimeout), "Time to wait for successful deployment. When given, or with --wait, also limits how long the rollout is monitored in the cluster. (env TIMEOUT)")
 	flag.StringSliceVar(&cfg.Variables, "var", getEnvStringSlice("VAR"), "Template variable in the form KEY=VALUE. Can be specified multiple times. (env VAR)")
 	flag.StringVar(&cfg.VariablesFile, "vars", os.Getenv("VARS"), "File containing template variables. (env VARS)")
-	flag.BoolVar(&cfg.Wait, "wait", getEnvBool("WAIT", false), "Block until deployment reaches final state (success, failure, error). (env WAIT)")
+	flag.BoolVar(&cfg.Wait, "wait", getEnvBool("WAIT", false), "Block until deployment reaches final state (success, failure, error). Interrupting cancels the deployment. (env WAIT)")
 
 	// Purposely do not expose the PollInterval variable
 	cfg.PollInterval = DefaultPollInterval
 
 	flag.Parse()
 
+	// Without an explicit timeout, the rollout is monitored for as long as the server decides
+	_, timeoutEnv := os.LookupEnv("TIMEOUT")
+	cfg.TimeoutSet = timeoutEnv || flag.CommandLine.Changed("timeout")
+
 	// Both owner and repository must be set in a valid request, but they are not required
 	if len(cfg.Owner) == 0 || len(cfg.Repository) == 0 {
 		cfg.Owner = ""
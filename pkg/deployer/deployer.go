@@ -13,6 +13,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -27,6 +29,8 @@ import (
 
 type TemplateVariables map[string]interface{}
 
+var documentSeparator = regexp.MustCompile(`^---(\s|$)`)
+
 type ActionsFormatter struct{}
 
 type ExitCode int
@@ -34,6 +38,8 @@ type ExitCode int
 const (
 	DeployAPIPath        = "/api/v1/deploy"
 	StatusAPIPath        = "/api/v1/status"
+	StatusStreamAPIPath  = "/api/v1/status/stream"
+	CancelAPIPath        = "/api/v1/deployments/%s/cancel"
 	DefaultPollInterval  = time.Second * 5
 	DefaultRef           = "master"
 	DefaultOwner         = "navikt"
@@ -45,6 +51,9 @@ const (
 	MalformedURLMsg       = "wrong format of deployment server URL"
 	ClusterRequiredMsg    = "cluster required; see https://doc.nais.io/clusters"
 	MalformedAPIKeyMsg    = "API key must be a hex encoded string"
+	TeamRequiredMsg       = "team required"
+	DeploymentRequiredMsg = "correlation ID, or both repository owner and name, required to look up a deployment"
+	InventoryRequiredMsg  = "inventory required when pruning, so that deployments from the same repository do not prune each other's resources"
 )
 
 // Kept separate to avoid skewing exit codes
@@ -59,6 +68,7 @@ const (
 	ExitInternalError
 	ExitTemplateError
 	ExitTimeout
+	ExitDeploymentCancelled
 )
 
 type Deployer struct {
@@ -71,7 +81,7 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
 	if err := validate(cfg); err != nil {
-		if !cfg.DryRun {
+		if !cfg.DryRun || cfg.Remote {
 			return ExitInvocationFailure, err
 		}
 
@@ -102,16 +112,21 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 		}
 	}
 
-	resources := make([]json.RawMessage, len(cfg.Resource))
+	paths, err := resourcePaths(cfg.Resource)
+	if err != nil {
+		return ExitInvocationFailure, err
+	}
+
+	resources := make([]json.RawMessage, 0, len(paths))
+	origins := make([]string, 0, len(paths))
 
-	for i, path := range cfg.Resource {
-		resources[i], err = fileAsJSON(path, templateVariables)
+	for _, path := range paths {
+		documents, err := fileAsJSON(path, templateVariables)
 		if err != nil {
-			if cfg.PrintPayload {
-				errStr := err.Error()[len(path)+2:]
-				line, er := detectErrorLine(errStr)
+			if docErr, ok := err.(*documentError); ok && cfg.PrintPayload {
+				line, er := detectErrorLine(docErr.err.Error())
 				if er == nil {
-					ctx := errorContext(string(resources[i]), line, 7)
+					ctx := errorContext(docErr.content, line, 7)
 					for _, l := range ctx {
 						fmt.Println(l)
 					}
@@ -119,14 +134,22 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 			}
 			return ExitTemplateError, err
 		}
+		for _, document := range documents {
+			resources = append(resources, document.data)
+			origins = append(origins, documentOrigin(path, document.index, len(documents)))
+		}
+	}
+
+	if len(resources) == 0 {
+		return ExitInvocationFailure, fmt.Errorf(ResourceRequiredMsg)
 	}
 
 	if len(cfg.Team) == 0 {
 		log.Infof("Team not explicitly specified; attempting auto-detection...")
-		for i, path := range cfg.Resource {
+		for i := range resources {
 			team := detectTeam(resources[i])
 			if len(team) > 0 {
-				log.Infof("Detected team '%s' in path %s", team, path)
+				log.Infof("Detected team '%s' in %s", team, origins[i])
 				cfg.Team = team
 				break
 			}
@@ -143,7 +166,7 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 		namespaces := make(map[string]interface{})
 		cfg.Environment = cfg.Cluster
 
-		for i := range cfg.Resource {
+		for i := range resources {
 			namespace := detectNamespace(resources[i])
 			namespaces[namespace] = new(interface{})
 		}
@@ -177,7 +200,7 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 		fmt.Printf(buf.String())
 	}
 
-	if cfg.DryRun {
+	if cfg.DryRun && !cfg.Remote {
 		return ExitSuccess, nil
 	}
 
@@ -225,6 +248,9 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 
 		log.Infof("message...: %s", response.Message)
 		log.Infof("logs......: %s", response.LogURL)
+		if len(response.RolloutTimeout) > 0 {
+			log.Infof("timeout...: %s", response.RolloutTimeout)
+		}
 
 		if resp.StatusCode == http.StatusServiceUnavailable && cfg.Retry {
 			log.Infof("retrying in %s...", cfg.PollInterval)
@@ -249,14 +275,59 @@ func (d *Deployer) Run(cfg Config) (ExitCode, error) {
 		return ExitNoDeployment, fmt.Errorf("deployment failed: %s", response.Message)
 	}
 
-	if !cfg.Wait {
+	// The outcome of a remote dry run is only available once it has finished.
+	if !cfg.Wait && !cfg.DryRun {
+		return ExitSuccess, nil
+	}
+
+	interrupted := d.cancelOnInterrupt(ctx, cancel, response.CorrelationID, decoded, *targetURL, cfg)
+
+	code, err := d.wait(ctx, response.CorrelationID, decoded, *targetURL, cfg)
+
+	select {
+	case <-interrupted:
+		return ExitDeploymentCancelled, fmt.Errorf("deployment cancelled by user")
+	default:
+	}
+
+	if cfg.DryRun && code == ExitSuccess && err == nil {
+		return printDiff(response.CorrelationID, decoded, *targetURL, cfg)
+	}
+
+	return code, err
+}
+
+// Print the changes a remote dry run would have made to the cluster, as a unified diff on standard output.
+func printDiff(deploymentID string, key []byte, targetURL url.URL, cfg Config) (ExitCode, error) {
+	_, code, response, err := check(deploymentID, key, targetURL, cfg)
+	if err != nil {
+		return code, err
+	}
+
+	if response == nil || len(response.Diff) == 0 {
+		log.Infof("Dry run completed; no resources would be changed")
 		return ExitSuccess, nil
 	}
 
+	fmt.Print(response.Diff)
+
+	return ExitSuccess, nil
+}
+
+// Wait for a deployment to reach its final state, by streaming its status if possible, and polling otherwise.
+func (d *Deployer) wait(ctx context.Context, deploymentID string, key []byte, targetURL url.URL, cfg Config) (ExitCode, error) {
+	log.Infof("Streaming deployment status until it has reached its final state...")
+
+	code, err, done := d.stream(ctx, deploymentID, key, targetURL, cfg)
+	if done {
+		return code, err
+	}
+
+	log.Warnf("Status streaming unavailable: %s", err)
 	log.Infof("Polling deployment status until it has reached its final state...")
 
 	for {
-		cont, status, err := check(response.CorrelationID, decoded, *targetURL, cfg)
+		cont, status, _, err := check(deploymentID, key, targetURL, cfg)
 
 		if !cont {
 			return status, err
@@ -291,25 +362,31 @@ func setupLogging(actions, quiet bool) {
 }
 
 // Check if a deployment has reached a terminal state.
+// If the deployment ID is empty, the most recent deployment from the configured repository is checked.
 // The first return value is true if the state might change, false otherwise.
-// Additionally, returns an error if any error occurred.
-func check(deploymentID string, key []byte, targetURL url.URL, cfg Config) (bool, ExitCode, error) {
+// Additionally, returns the status response, and an error if any error occurred.
+func check(deploymentID string, key []byte, targetURL url.URL, cfg Config) (bool, ExitCode, *api_v1_status.StatusResponse, error) {
 	statusReq := &api_v1_status.StatusRequest{
 		DeploymentID: deploymentID,
 		Team:         cfg.Team,
 		Timestamp:    api_v1.Timestamp(time.Now().Unix()),
 	}
 
+	if len(deploymentID) == 0 {
+		statusReq.Owner = cfg.Owner
+		statusReq.Repository = cfg.Repository
+	}
+
 	payload, err := json.Marshal(statusReq)
 	if err != nil {
-		return false, ExitInternalError, fmt.Errorf("unable to marshal status request: %s", err)
+		return false, ExitInternalError, nil, fmt.Errorf("unable to marshal status request: %s", err)
 	}
 
 	targetURL.Path = StatusAPIPath
 	buf := bytes.NewBuffer(payload)
 	req, err := http.NewRequest(http.MethodPost, targetURL.String(), buf)
 	if err != nil {
-		return false, ExitInternalError, fmt.Errorf("internal error creating http request: %v", err)
+		return false, ExitInternalError, nil, fmt.Errorf("internal error creating http request: %v", err)
 	}
 
 	signature := sign(payload, key)
@@ -319,45 +396,55 @@ func check(deploymentID string, key []byte, targetURL url.URL, cfg Config) (bool
 	response := &api_v1_status.StatusResponse{}
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return true, ExitInternalError, fmt.Errorf("error making request: %s", err)
+		return true, ExitInternalError, nil, fmt.Errorf("error making request: %s", err)
 	}
 
 	decoder := json.NewDecoder(resp.Body)
 	err = decoder.Decode(response)
 	if err != nil {
-		return true, ExitInternalError, fmt.Errorf("received invalid response from server: %s: %s", resp.Status, err)
+		return true, ExitInternalError, nil, fmt.Errorf("received invalid response from server: %s: %s", resp.Status, err)
 	}
 
 	switch {
 	case resp == nil:
-		return false, ExitInternalError, fmt.Errorf("null reply from server")
+		return false, ExitInternalError, nil, fmt.Errorf("null reply from server")
+	case resp.StatusCode == http.StatusNotFound:
+		return false, ExitNoDeployment, response, fmt.Errorf("%s", response.Message)
 	case resp.StatusCode >= 400 && resp.StatusCode < 500:
-		return false, ExitInternalError, fmt.Errorf("bad request: %s", response.Message)
-	case resp.StatusCode != http.StatusOK:
-		log.Infof("status....: %s", resp.Status)
-		log.Infof("message...: %s", response.Message)
-		return true, ExitInternalError, nil
+		return false, ExitInternalError, response, fmt.Errorf("bad request: %s", response.Message)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return true, ExitInternalError, response, fmt.Errorf("server error: %s: %s", resp.Status, response.Message)
 	case response.Status == nil:
 		fallthrough
 	case resp.StatusCode == http.StatusNoContent:
-		return true, ExitSuccess, nil
+		return true, ExitSuccess, response, nil
 	}
 
 	log.Infof("deployment: %s: %s", *response.Status, response.Message)
 
-	status := types.GithubDeploymentState(types.GithubDeploymentState_value[*response.Status])
+	cont, code := stateExitCode(*response.Status)
+
+	return cont, code, response, nil
+}
+
+// Map a deployment state to an exit code.
+// The first return value is true if the state might change, false otherwise.
+func stateExitCode(state string) (bool, ExitCode) {
+	status := types.GithubDeploymentState(types.GithubDeploymentState_value[state])
 	switch status {
 	case types.GithubDeploymentState_success:
-		return false, ExitSuccess, nil
+		return false, ExitSuccess
 	case types.GithubDeploymentState_error:
-		return false, ExitDeploymentError, nil
+		return false, ExitDeploymentError
 	case types.GithubDeploymentState_failure:
-		return false, ExitDeploymentFailure, nil
+		return false, ExitDeploymentFailure
 	case types.GithubDeploymentState_inactive:
-		return false, ExitDeploymentInactive, nil
+		return false, ExitDeploymentInactive
+	case types.GithubDeploymentState_cancelled:
+		return false, ExitDeploymentCancelled
 	}
 
-	return true, ExitSuccess, nil
+	return true, ExitSuccess
 }
 
 func mkpayload(w io.Writer, resources json.RawMessage, cfg Config) error {
@@ -369,9 +456,20 @@ func mkpayload(w io.Writer, resources json.RawMessage, cfg Config) error {
 		Ref:         cfg.Ref,
 		Owner:       cfg.Owner,
 		Repository:  cfg.Repository,
+		Deployer:    cfg.Deployer,
+		DryRun:      cfg.DryRun && cfg.Remote,
+		Rollback:    cfg.Rollback,
+		Inventory:   cfg.Inventory,
+		Prune:       cfg.Prune,
 		Timestamp:   time.Now().Unix(),
 	}
 
+	// Deployd monitors the rollout for as long as we are prepared to wait for it.
+	// Otherwise, the server default applies.
+	if cfg.Wait || cfg.TimeoutSet {
+		req.RolloutTimeout = cfg.Timeout.String()
+	}
+
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 
@@ -491,7 +589,102 @@ func errorContext(content string, line int, around int) []string {
 	return context
 }
 
-func fileAsJSON(path string, ctx TemplateVariables) (json.RawMessage, error) {
+// documentError is returned when a single document within a resource file
+// cannot be converted to JSON. The document content is retained so that the
+// offending line can be highlighted using errorContext.
+type documentError struct {
+	path    string
+	index   int
+	content string
+	err     error
+}
+
+func (e *documentError) Error() string {
+	return fmt.Sprintf("%s: document %d: %s", e.path, e.index+1, e.err)
+}
+
+// document is a single resource parsed from a resource file. The index is the
+// position of the document among all documents in the file, including skipped
+// ones, so that it matches the numbering used by documentError.
+type document struct {
+	index int
+	data  json.RawMessage
+}
+
+// Human readable description of where a resource came from.
+func documentOrigin(path string, index, total int) string {
+	if total == 1 {
+		return fmt.Sprintf("path %s", path)
+	}
+	return fmt.Sprintf("path %s (document %d)", path, index+1)
+}
+
+// Expand a list of resource arguments into a list of files.
+//
+// Each argument can be either a file, a directory, or a glob pattern.
+// Directories are expanded into all YAML and JSON files they contain, non-recursively.
+// Glob patterns that do not match any files are treated as errors.
+func resourcePaths(args []string) ([]string, error) {
+	paths := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("%s: pattern does not match any files", arg)
+			}
+			paths = append(paths, matches...)
+			continue
+		}
+
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			// errors from non-existing files are reported when the file is opened
+			paths = append(paths, arg)
+			continue
+		}
+
+		files, err := ioutil.ReadDir(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: read directory: %s", arg, err)
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			switch strings.ToLower(filepath.Ext(file.Name())) {
+			case ".yaml", ".yml", ".json":
+				paths = append(paths, filepath.Join(arg, file.Name()))
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// Split a YAML stream into separate documents.
+// Documents are separated by a line starting with `---`.
+func splitDocuments(data []byte) []string {
+	documents := make([]string, 0)
+	current := make([]string, 0)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if documentSeparator.MatchString(line) {
+			documents = append(documents, strings.Join(current, "\n"))
+			current = []string{strings.TrimPrefix(line, "---")}
+			continue
+		}
+		current = append(current, line)
+	}
+
+	return append(documents, strings.Join(current, "\n"))
+}
+
+func fileAsJSON(path string, ctx TemplateVariables) ([]document, error) {
 	file, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("%s: open file: %s", path, err)
@@ -503,13 +696,32 @@ func fileAsJSON(path string, ctx TemplateVariables) (json.RawMessage, error) {
 		return nil, fmt.Errorf("%s: %s", path, errMsg)
 	}
 
-	// Since JSON is a subset of YAML, passing JSON through this method is a no-op.
-	data, err := yaml.YAMLToJSON(templated)
-	if err != nil {
-		return templated, fmt.Errorf("%s: %s", path, err)
+	documents := make([]document, 0)
+
+	for index, content := range splitDocuments(templated) {
+		// Since JSON is a subset of YAML, passing JSON through this method is a no-op.
+		data, err := yaml.YAMLToJSON([]byte(content))
+		if err != nil {
+			return nil, &documentError{
+				path:    path,
+				index:   index,
+				content: content,
+				err:     err,
+			}
+		}
+
+		// Documents containing only whitespace or comments are skipped.
+		if string(data) == "null" {
+			continue
+		}
+
+		documents = append(documents, document{
+			index: index,
+			data:  data,
+		})
 	}
 
-	return data, nil
+	return documents, nil
 }
 
 func (a *ActionsFormatter) Format(e *log.Entry) ([]byte, error) {
@@ -547,5 +759,9 @@ func validate(cfg Config) error {
 		return fmt.Errorf(APIKeyRequiredMsg)
 	}
 
+	if cfg.Prune && len(cfg.Inventory) == 0 {
+		return fmt.Errorf(InventoryRequiredMsg)
+	}
+
 	return nil
 }
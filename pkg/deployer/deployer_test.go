@@ -10,8 +10,11 @@ import (
 
 	"github.com/navikt/deployment/pkg/deployer"
 	"github.com/navikt/deployment/pkg/pb"
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
 	"github.com/navikt/deployment/pkg/hookd/api/v1/deploy"
+	"github.com/navikt/deployment/pkg/hookd/api/v1/deployments"
 	"github.com/navikt/deployment/pkg/hookd/api/v1/status"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,6 +92,109 @@ func TestHappyPathForAlert(t *testing.T) {
 	assert.Equal(t, exitCode, deployer.ExitSuccess)
 }
 
+func TestMultipleDocumentsDirectoriesAndGlobs(t *testing.T) {
+	for _, testCase := range []struct {
+		resources []string
+		kinds     []string
+	}{
+		{[]string{"testdata/multi/nais.yaml"}, []string{"Application", "ConfigMap"}},
+		{[]string{"testdata/multi"}, []string{"Alert", "Application", "ConfigMap"}},
+		{[]string{"testdata/multi/*.yaml", "testdata/alert.json"}, []string{"Application", "ConfigMap", "Alert"}},
+	} {
+		cfg := validConfig()
+		cfg.Resource = testCase.resources
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+
+			deployRequest := api_v1_deploy.DeploymentRequest{}
+
+			if err := json.NewDecoder(r.Body).Decode(&deployRequest); err != nil {
+				t.Error(err)
+			}
+
+			resources := make([]struct {
+				Kind string `json:"kind"`
+			}, 0)
+
+			err := json.Unmarshal(deployRequest.Resources, &resources)
+			assert.NoError(t, err)
+
+			kinds := make([]string, len(resources))
+			for i := range resources {
+				kinds[i] = resources[i].Kind
+			}
+
+			assert.Equal(t, testCase.kinds, kinds)
+			assert.Equal(t, "aura", deployRequest.Team, "auto-detection of team works")
+
+			b, err := json.Marshal(&api_v1_deploy.DeploymentResponse{})
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			w.Write(b)
+		}))
+
+		d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+		exitCode, err := d.Run(cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, deployer.ExitSuccess, exitCode)
+		server.Close()
+	}
+}
+
+func TestInvalidDocumentIndex(t *testing.T) {
+	cfg := validConfig()
+	cfg.Resource = []string{"testdata/invalid-document.yaml"}
+	d := deployer.Deployer{}
+	exitCode, err := d.Run(cfg)
+	assert.Equal(t, deployer.ExitTemplateError, exitCode)
+	assert.Contains(t, err.Error(), "testdata/invalid-document.yaml: document 2: yaml: line 5")
+}
+
+func TestSkippedDocumentsKeepNumbering(t *testing.T) {
+	cfg := validConfig()
+	cfg.Resource = []string{"testdata/skipped-invalid-document.yaml"}
+	d := deployer.Deployer{}
+	exitCode, err := d.Run(cfg)
+	assert.Equal(t, deployer.ExitTemplateError, exitCode)
+	assert.Contains(t, err.Error(), "testdata/skipped-invalid-document.yaml: document 3: yaml: line 5")
+
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		b, err := json.Marshal(&api_v1_deploy.DeploymentResponse{})
+		assert.NoError(t, err)
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	cfg.Resource = []string{"testdata/skipped-documents.yaml"}
+	d = deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+	exitCode, err = d.Run(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, deployer.ExitSuccess, exitCode)
+
+	messages := make([]string, 0)
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Contains(t, messages, "Detected team 'aura' in path testdata/skipped-documents.yaml (document 3)")
+}
+
+func TestGlobWithoutMatches(t *testing.T) {
+	cfg := validConfig()
+	cfg.Resource = []string{"testdata/*.nonexistent"}
+	d := deployer.Deployer{}
+	exitCode, err := d.Run(cfg)
+	assert.Equal(t, deployer.ExitInvocationFailure, exitCode)
+	assert.Contains(t, err.Error(), "pattern does not match any files")
+}
+
 func TestWaitForComplete(t *testing.T) {
 	requests := 0
 	cfg := validConfig()
@@ -163,6 +269,47 @@ func TestWaitForTheInevitableEventualFailure(t *testing.T) {
 	assert.Equal(t, exitCode, deployer.ExitDeploymentFailure)
 }
 
+func TestWaitForStreamedStatus(t *testing.T) {
+	cfg := validConfig()
+	cfg.Wait = true
+	cfg.PollInterval = time.Millisecond * 1
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marshaler := json.NewEncoder(w)
+		switch r.RequestURI {
+		case "/api/v1/deploy":
+			w.WriteHeader(http.StatusCreated)
+			marshaler.Encode(&api_v1_deploy.DeploymentResponse{CorrelationID: "123"})
+		case "/api/v1/status/stream":
+			statusRequest := api_v1_status.StatusRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&statusRequest); err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, "123", statusRequest.DeploymentID)
+
+			w.WriteHeader(http.StatusOK)
+			for _, state := range []pb.GithubDeploymentState{
+				pb.GithubDeploymentState_queued,
+				pb.GithubDeploymentState_in_progress,
+				pb.GithubDeploymentState_failure,
+			} {
+				status := state.String()
+				marshaler.Encode(&api_v1_status.StatusResponse{
+					Status: &status,
+				})
+			}
+		default:
+			t.Errorf("unexpected request to %s", r.RequestURI)
+		}
+	}))
+
+	d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+	exitCode, err := d.Run(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, exitCode, deployer.ExitDeploymentFailure)
+}
+
 func TestValidationFailures(t *testing.T) {
 	for _, testCase := range []struct {
 		errorMsg  string
@@ -172,6 +319,7 @@ func TestValidationFailures(t *testing.T) {
 		{deployer.APIKeyRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.APIKey = ""; return cfg }},
 		{deployer.ResourceRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.Resource = nil; return cfg }},
 		{deployer.MalformedAPIKeyMsg, func(cfg deployer.Config) deployer.Config { cfg.APIKey = "malformed"; return cfg }},
+		{deployer.InventoryRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.Prune = true; return cfg }},
 	} {
 		cfg := validConfig()
 		cfg = testCase.transform(cfg)
@@ -194,3 +342,287 @@ func validConfig() deployer.Config {
 	cfg.APIKey = "1234567812345678"
 	return cfg
 }
+
+func TestStatusAndHistory(t *testing.T) {
+	for _, testCase := range []struct {
+		deploymentID string
+		status       string
+		exitCode     deployer.ExitCode
+	}{
+		{"123", pb.GithubDeploymentState_success.String(), deployer.ExitSuccess},
+		{"123", pb.GithubDeploymentState_failure.String(), deployer.ExitDeploymentFailure},
+		{"", pb.GithubDeploymentState_error.String(), deployer.ExitDeploymentError},
+		{"", pb.GithubDeploymentState_in_progress.String(), deployer.ExitSuccess},
+	} {
+		cfg := validConfig()
+		cfg.Team = "aura"
+		cfg.Owner = "navikt"
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			statusRequest := api_v1_status.StatusRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&statusRequest); err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, "/api/v1/status", r.RequestURI)
+			assert.Equal(t, testCase.deploymentID, statusRequest.DeploymentID)
+			if len(testCase.deploymentID) == 0 {
+				assert.Equal(t, "navikt", statusRequest.Owner)
+				assert.Equal(t, "myrepo", statusRequest.Repository)
+			}
+
+			status := testCase.status
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(&api_v1_status.StatusResponse{
+				DeploymentID: "123",
+				Status:       &status,
+				History: []api_v1_status.StatusEntry{
+					{Status: pb.GithubDeploymentState_queued.String()},
+					{Status: status},
+				},
+			})
+		}))
+
+		d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+		exitCode, err := d.Status(cfg, testCase.deploymentID)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.exitCode, exitCode)
+
+		exitCode, err = d.History(cfg, testCase.deploymentID)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.exitCode, exitCode)
+
+		server.Close()
+	}
+}
+
+func TestStatusDeploymentNotFound(t *testing.T) {
+	cfg := validConfig()
+	cfg.Team = "aura"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&api_v1_status.StatusResponse{
+			Message: "deployment not found",
+		})
+	}))
+	defer server.Close()
+
+	d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+	exitCode, err := d.Status(cfg, "123")
+	assert.Error(t, err)
+	assert.Equal(t, deployer.ExitNoDeployment, exitCode)
+}
+
+func TestStatusServerError(t *testing.T) {
+	cfg := validConfig()
+	cfg.Team = "aura"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(&api_v1_status.StatusResponse{
+			Message: "upstream unavailable",
+		})
+	}))
+	defer server.Close()
+
+	d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+	exitCode, err := d.Status(cfg, "123")
+	assert.Equal(t, deployer.ExitInternalError, exitCode)
+	assert.EqualError(t, err, "server error: 502 Bad Gateway: upstream unavailable")
+}
+
+func TestStatusValidationFailures(t *testing.T) {
+	for _, testCase := range []struct {
+		errorMsg  string
+		transform func(cfg deployer.Config) deployer.Config
+	}{
+		{deployer.APIKeyRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.APIKey = ""; return cfg }},
+		{deployer.TeamRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.Team = ""; return cfg }},
+		{deployer.DeploymentRequiredMsg, func(cfg deployer.Config) deployer.Config { cfg.Repository = ""; return cfg }},
+	} {
+		cfg := validConfig()
+		cfg.Team = "aura"
+		cfg.Owner = "navikt"
+		cfg = testCase.transform(cfg)
+		d := deployer.Deployer{}
+		exitCode, err := d.Status(cfg, "")
+		assert.Equal(t, deployer.ExitInvocationFailure, exitCode)
+		assert.Contains(t, err.Error(), testCase.errorMsg)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	for _, testCase := range []struct {
+		deploymentID string
+		statusCode   int
+		exitCode     deployer.ExitCode
+	}{
+		{"123", http.StatusAccepted, deployer.ExitSuccess},
+		{"", http.StatusAccepted, deployer.ExitSuccess},
+		{"123", http.StatusNotFound, deployer.ExitNoDeployment},
+		{"123", http.StatusConflict, deployer.ExitInternalError},
+	} {
+		cfg := validConfig()
+		cfg.Team = "aura"
+		cfg.Owner = "navikt"
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v1/status":
+				status := pb.GithubDeploymentState_in_progress.String()
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(&api_v1_status.StatusResponse{
+					DeploymentID: "123",
+					Status:       &status,
+				})
+			case "/api/v1/deployments/123/cancel":
+				cancelRequest := api_v1_deployments.CancelRequest{}
+				if err := json.NewDecoder(r.Body).Decode(&cancelRequest); err != nil {
+					t.Error(err)
+				}
+				assert.Equal(t, "123", cancelRequest.DeploymentID)
+				assert.Equal(t, "aura", cancelRequest.Team)
+				assert.NotEmpty(t, r.Header.Get(api_v1.SignatureHeader))
+
+				w.WriteHeader(testCase.statusCode)
+				json.NewEncoder(w).Encode(&api_v1_deployments.CancelResponse{
+					Message: http.StatusText(testCase.statusCode),
+				})
+			default:
+				t.Errorf("unexpected request to %s", r.RequestURI)
+			}
+		}))
+
+		d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+		exitCode, err := d.Cancel(cfg, testCase.deploymentID)
+		if testCase.exitCode == deployer.ExitSuccess {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+		assert.Equal(t, testCase.exitCode, exitCode)
+
+		server.Close()
+	}
+}
+
+func TestRolloutTimeout(t *testing.T) {
+	for _, testCase := range []struct {
+		name       string
+		wait       bool
+		timeoutSet bool
+		expected   string
+	}{
+		{name: "server default", expected: ""},
+		{name: "explicit timeout", timeoutSet: true, expected: "10m0s"},
+		{name: "waiting for deployment", wait: true, expected: "10m0s"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Timeout = time.Minute * 10
+			cfg.TimeoutSet = testCase.timeoutSet
+			cfg.Wait = testCase.wait
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				marshaler := json.NewEncoder(w)
+				switch r.RequestURI {
+				case "/api/v1/deploy":
+					deployRequest := api_v1_deploy.DeploymentRequest{}
+					if err := json.NewDecoder(r.Body).Decode(&deployRequest); err != nil {
+						t.Error(err)
+					}
+					assert.Equal(t, testCase.expected, deployRequest.RolloutTimeout)
+
+					w.WriteHeader(http.StatusCreated)
+					marshaler.Encode(&api_v1_deploy.DeploymentResponse{CorrelationID: "123"})
+				default:
+					status := pb.GithubDeploymentState_success.String()
+					w.WriteHeader(http.StatusOK)
+					marshaler.Encode(&api_v1_status.StatusResponse{
+						DeploymentID: "123",
+						Status:       &status,
+					})
+				}
+			}))
+			defer server.Close()
+
+			d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+			exitCode, err := d.Run(cfg)
+			assert.NoError(t, err)
+			assert.Equal(t, deployer.ExitSuccess, exitCode)
+		})
+	}
+}
+
+func TestRemoteDryRun(t *testing.T) {
+	cfg := validConfig()
+	cfg.DryRun = true
+	cfg.Remote = true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marshaler := json.NewEncoder(w)
+		switch r.RequestURI {
+		case "/api/v1/deploy":
+			deployRequest := api_v1_deploy.DeploymentRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&deployRequest); err != nil {
+				t.Error(err)
+			}
+			assert.True(t, deployRequest.DryRun)
+
+			w.WriteHeader(http.StatusCreated)
+			marshaler.Encode(&api_v1_deploy.DeploymentResponse{CorrelationID: "123"})
+		case "/api/v1/status/stream":
+			status := pb.GithubDeploymentState_success.String()
+			w.WriteHeader(http.StatusOK)
+			marshaler.Encode(&api_v1_status.StatusResponse{
+				Status: &status,
+			})
+		case "/api/v1/status":
+			status := pb.GithubDeploymentState_success.String()
+			w.WriteHeader(http.StatusOK)
+			marshaler.Encode(&api_v1_status.StatusResponse{
+				DeploymentID: "123",
+				Status:       &status,
+				Diff:         "--- live/v1/ConfigMap/default/foo\n+++ dry-run/v1/ConfigMap/default/foo\n",
+			})
+		default:
+			t.Errorf("unexpected request to %s", r.RequestURI)
+		}
+	}))
+	defer server.Close()
+
+	d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+	exitCode, err := d.Run(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, deployer.ExitSuccess, exitCode)
+}
+
+func TestRollback(t *testing.T) {
+	cfg := validConfig()
+	cfg.Rollback = true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deployRequest := api_v1_deploy.DeploymentRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&deployRequest); err != nil {
+			t.Error(err)
+		}
+		assert.True(t, deployRequest.Rollback)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&api_v1_deploy.DeploymentResponse{})
+	}))
+	defer server.Close()
+
+	d := deployer.Deployer{Client: server.Client(), DeployServer: server.URL}
+
+	exitCode, err := d.Run(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, deployer.ExitSuccess, exitCode)
+}
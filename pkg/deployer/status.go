@@ -0,0 +1,84 @@
+package deployer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Status prints the current state and log URL of an existing deployment.
+//
+// The deployment is identified by its correlation ID. If the correlation ID is empty,
+// the most recent deployment made by the team from the configured repository is used.
+func (d *Deployer) Status(cfg Config, deploymentID string) (ExitCode, error) {
+	return d.query(cfg, deploymentID, false)
+}
+
+// History prints the full status timeline of an existing deployment,
+// identified the same way as in Status.
+func (d *Deployer) History(cfg Config, deploymentID string) (ExitCode, error) {
+	return d.query(cfg, deploymentID, true)
+}
+
+func (d *Deployer) query(cfg Config, deploymentID string, history bool) (ExitCode, error) {
+	setupLogging(cfg.Actions, cfg.Quiet)
+
+	if err := validateQuery(cfg, deploymentID); err != nil {
+		return ExitInvocationFailure, err
+	}
+
+	decoded, err := hex.DecodeString(cfg.APIKey)
+	if err != nil {
+		return ExitInvocationFailure, fmt.Errorf("%s: %s", MalformedAPIKeyMsg, err)
+	}
+
+	targetURL, err := url.Parse(d.DeployServer)
+	if err != nil {
+		return ExitInvocationFailure, fmt.Errorf("%s: %s", MalformedURLMsg, err)
+	}
+
+	_, code, response, err := check(deploymentID, decoded, *targetURL, cfg)
+	if err != nil {
+		return code, err
+	}
+
+	if response == nil || response.Status == nil {
+		return ExitNoDeployment, fmt.Errorf("deployment has no status yet")
+	}
+
+	fmt.Printf("deployment: %s\n", response.DeploymentID)
+	fmt.Printf("status....: %s\n", *response.Status)
+	fmt.Printf("message...: %s\n", response.Message)
+	fmt.Printf("logs......: %s\n", response.LogURL)
+
+	if history {
+		fmt.Println()
+		for _, entry := range response.History {
+			fmt.Printf("%s  %-12s %s\n", entry.Created.Format(time.RFC3339), entry.Status, entry.Message)
+		}
+	}
+
+	return code, nil
+}
+
+func validateQuery(cfg Config, deploymentID string) error {
+	_, err := url.Parse(cfg.DeployServerURL)
+	if err != nil {
+		return fmt.Errorf("%s: %s", MalformedURLMsg, err)
+	}
+
+	if len(cfg.APIKey) == 0 {
+		return fmt.Errorf(APIKeyRequiredMsg)
+	}
+
+	if len(cfg.Team) == 0 {
+		return fmt.Errorf(TeamRequiredMsg)
+	}
+
+	if len(deploymentID) == 0 && (len(cfg.Owner) == 0 || len(cfg.Repository) == 0) {
+		return fmt.Errorf(DeploymentRequiredMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/api/v1/status"
+	log "github.com/sirupsen/logrus"
+)
+
+// Wait for a deployment to reach a terminal state by consuming the status stream from the server.
+//
+// The last return value is true if the deployment reached a terminal state or the timeout expired.
+// If false, the stream could not be used, and the caller should fall back to polling.
+func (d *Deployer) stream(ctx context.Context, deploymentID string, key []byte, targetURL url.URL, cfg Config) (ExitCode, error, bool) {
+	statusReq := &api_v1_status.StatusRequest{
+		DeploymentID: deploymentID,
+		Team:         cfg.Team,
+		Timestamp:    api_v1.Timestamp(time.Now().Unix()),
+	}
+
+	payload, err := json.Marshal(statusReq)
+	if err != nil {
+		return ExitInternalError, fmt.Errorf("unable to marshal status request: %s", err), false
+	}
+
+	targetURL.Path = StatusStreamAPIPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL.String(), bytes.NewBuffer(payload))
+	if err != nil {
+		return ExitInternalError, fmt.Errorf("internal error creating http request: %v", err), false
+	}
+
+	req.Header.Add("content-type", "application/json")
+	req.Header.Add(api_v1.SignatureHeader, sign(payload, key))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ExitTimeout, fmt.Errorf("timeout waiting for deploy to complete"), true
+		}
+		return ExitInternalError, fmt.Errorf("error making request: %s", err), false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ExitInternalError, fmt.Errorf("server responded with %s", resp.Status), false
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		response := &api_v1_status.StatusResponse{}
+		err := decoder.Decode(response)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ExitTimeout, fmt.Errorf("timeout waiting for deploy to complete"), true
+			}
+			return ExitInternalError, fmt.Errorf("status stream interrupted: %s", err), false
+		}
+
+		if response.Status == nil {
+			continue
+		}
+
+		log.Infof("deployment: %s: %s", *response.Status, response.Message)
+
+		cont, code := stateExitCode(*response.Status)
+		if !cont {
+			return code, nil, true
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/navikt/deployment/pkg/pb"
+	"github.com/navikt/deployment/pkg/hookd/database"
 	database_mapper "github.com/navikt/deployment/pkg/hookd/database/mapper"
 	"github.com/navikt/deployment/pkg/hookd/github"
 	"github.com/navikt/deployment/pkg/hookd/metrics"
@@ -17,6 +18,7 @@ import (
 var (
 	requestTimeout  = time.Second * 5
 	errNoRepository = fmt.Errorf("no repository specified")
+	errDryRun       = fmt.Errorf("dry runs are not synchronized")
 )
 
 func (s *deployServer) githubLoop() {
@@ -47,7 +49,7 @@ func (s *deployServer) githubLoop() {
 			logger := log.WithFields(status.LogFields())
 			err := s.createGithubDeploymentStatus(status)
 			switch err {
-			case errNoRepository:
+			case errNoRepository, errDryRun:
 				logger.Tracef("Not syncing deployment to GitHub: %s", err)
 			case nil:
 				logger.Tracef("Synchronized deployment status to GitHub")
@@ -108,6 +110,10 @@ func (s *deployServer) createGithubDeploymentStatus(status pb.DeploymentStatus)
 		return fmt.Errorf("get deployment from database: %s", err)
 	}
 
+	if deploy.DryRun {
+		return errDryRun
+	}
+
 	if deploy.GitHubID == nil {
 		return fmt.Errorf("GitHub deployment ID not recorded in database")
 	}
@@ -121,7 +127,67 @@ func (s *deployServer) createGithubDeploymentStatus(status pb.DeploymentStatus)
 	return nil
 }
 
+// SendDeploymentRequest persists a deployment request and delivers it to its cluster.
+// If the cluster is offline, the request stays queued and is delivered once the cluster reconnects.
+// Requests are redelivered if not acknowledged by deployd in time, and kept until their deployment has finished.
+// An error is returned only if the request could not be persisted.
+//
+// The lock is only held while sending, so that a slow database or GitHub does not block other streams.
+// If the cluster reconnects right after the request is persisted, it might be delivered twice;
+// deployd ignores redelivered requests.
 func (s *deployServer) SendDeploymentRequest(ctx context.Context, request pb.DeploymentRequest) error {
+	logger := log.WithFields(request.LogFields())
+
+	err := s.enqueue(ctx, request)
+	if err != nil {
+		return fmt.Errorf("queue deployment request: %s", err)
+	}
+
+	s.lock.Lock()
+	err = s.send(request)
+	instance := s.instances[request.GetCluster()]
+	s.lock.Unlock()
+
+	if err != nil {
+		logger.Warnf("Unable to send deployment request: %s", err)
+		logger.Infof("Deployment request queued until cluster '%s' comes online", request.GetCluster())
+	} else {
+		s.markDelivered(request, instance)
+	}
+
+	if !request.GetDryRun() {
+		s.requests <- request
+	}
+
+	return nil
+}
+
+// Send a deployment request, and record which deployd instance it was sent to.
+// Must be called with the lock held.
+func (s *deployServer) deliver(request pb.DeploymentRequest) error {
+	err := s.send(request)
+	if err != nil {
+		return err
+	}
+
+	s.markDelivered(request, s.instances[request.GetCluster()])
+
+	return nil
+}
+
+// Record that a deployment request was sent to a deployd instance.
+func (s *deployServer) markDelivered(request pb.DeploymentRequest, instance string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	err := s.queue.MarkDeploymentRequestDelivered(ctx, request.GetDeliveryID(), instance)
+	if err != nil {
+		// Not fatal; the request will be redelivered on reconnect, or expire.
+		log.WithFields(request.LogFields()).Errorf("Unable to record delivery of deployment request: %s", err)
+	}
+}
+
+func (s *deployServer) send(request pb.DeploymentRequest) error {
 	err := s.clusterOnline(request.Cluster)
 	if err != nil {
 		return err
@@ -133,8 +199,6 @@ func (s *deployServer) SendDeploymentRequest(ctx context.Context, request pb.Dep
 
 	log.WithFields(request.LogFields()).Infof("Sent deployment request")
 
-	s.requests <- request
-
 	return nil
 }
 
@@ -149,6 +213,11 @@ func (s *deployServer) clusterOnline(clusterName string) error {
 func (s *deployServer) HandleDeploymentStatus(ctx context.Context, status pb.DeploymentStatus) error {
 	dbStatus := database_mapper.DeploymentStatus(status)
 	err := s.db.WriteDeploymentStatus(ctx, dbStatus)
+	if database.IsErrDuplicate(err) {
+		// deployd retries statuses until they are acknowledged, so this one has been handled already.
+		log.WithFields(status.LogFields()).Infof("Ignoring duplicate deployment status %s", status.GetId())
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("write to database: %s", err)
 	}
@@ -157,7 +226,17 @@ func (s *deployServer) HandleDeploymentStatus(ctx context.Context, status pb.Dep
 
 	log.WithFields(status.LogFields()).Infof("Saved deployment status")
 
-	s.statuses <- status
+	s.subscribers.publish(status)
+
+	// Finished deployments will not be resumed, so their requests are no longer needed.
+	if status.GetState().Finished() {
+		s.dequeue(status.GetDeliveryID())
+	}
+
+	// Progress is only interesting while following a deployment, and would flood the GitHub status history.
+	if !status.GetProgress() {
+		s.statuses <- status
+	}
 
 	return nil
 }
@@ -0,0 +1,81 @@
+package deployserver
+
+import (
+	"context"
+	"fmt"
+
+	database_mapper "github.com/navikt/deployment/pkg/hookd/database/mapper"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+// CancelDeployment aborts a deployment that is queued for, or running in, the given cluster.
+// Requests that have not yet been acknowledged are cancelled right away. If deployd has acknowledged
+// the request, it is asked to stop the deployment, and reports the cancelled status itself.
+// An error is returned if the cancellation could not be passed on to the cluster.
+func (s *deployServer) CancelDeployment(ctx context.Context, deploymentID, cluster string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	queued, err := s.queue.PendingDeploymentRequests(ctx, cluster)
+	if err != nil {
+		return fmt.Errorf("retrieve queued deployment requests: %s", err)
+	}
+
+	for _, q := range queued {
+		if q.ID != deploymentID {
+			continue
+		}
+
+		request, err := database_mapper.PbDeploymentRequest(q)
+		if err != nil {
+			return fmt.Errorf("decode queued deployment request: %s", err)
+		}
+
+		// The request might be running in deployd already, even though it is not acknowledged yet.
+		// deployd might just as well have lost it, and would then never report a final status,
+		// so the deployment is marked as cancelled here in any case.
+		if q.Delivered != nil {
+			err = s.sendCancel(*request)
+			if err != nil {
+				log.WithFields(request.LogFields()).Warnf("Unable to pass cancellation on to deployd: %s", err)
+			}
+		}
+
+		err = s.HandleDeploymentStatus(ctx, *pb.NewCancelledStatus(*request))
+		if err != nil {
+			return err
+		}
+
+		s.dequeue(q.ID)
+		log.WithFields(request.LogFields()).Infof("Queued deployment request cancelled")
+
+		return nil
+	}
+
+	return s.sendCancel(pb.DeploymentRequest{
+		DeliveryID: deploymentID,
+		Cluster:    cluster,
+	})
+}
+
+// Ask deployd to stop a running deployment.
+// Cancellations are not queued, as they are meaningless once deployd has restarted.
+// Must be called with the lock held.
+func (s *deployServer) sendCancel(request pb.DeploymentRequest) error {
+	request.Cancel = true
+
+	err := s.clusterOnline(request.GetCluster())
+	if err != nil {
+		return err
+	}
+
+	err = s.streams[request.GetCluster()].Send(&request)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(request.LogFields()).Infof("Sent cancellation request")
+
+	return nil
+}
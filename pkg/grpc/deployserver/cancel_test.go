@@ -0,0 +1,75 @@
+package deployserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelDeployment(t *testing.T) {
+	for _, testCase := range []struct {
+		name         string
+		delivered    bool
+		acknowledged bool
+		online       bool
+		sent         []string
+		cancelled    bool
+	}{
+		{name: "queued while offline", cancelled: true},
+		{name: "delivered but not acknowledged", delivered: true, online: true, sent: []string{"123"}, cancelled: true},
+		{name: "delivered before disconnect", delivered: true, cancelled: true},
+		{name: "acknowledged", delivered: true, acknowledged: true, online: true, sent: []string{"123"}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			now := time.Now()
+			queue := &queueStore{}
+			s := testServer(queue)
+			stream := &requestStream{}
+			if testCase.online {
+				stream = connect(s, "dev")
+			}
+
+			queued(t, s, request("123", "dev", now, now.Add(time.Minute)))
+			if testCase.delivered {
+				queue.MarkDeploymentRequestDelivered(context.Background(), "123", "dev-instance")
+			}
+			if testCase.acknowledged {
+				queue.AcknowledgeDeploymentRequest(context.Background(), "123", "dev-instance")
+			}
+
+			err := s.CancelDeployment(context.Background(), "123", "dev")
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.sent, stream.sent)
+
+			statuses := s.db.(*statusStore).statuses
+			if testCase.cancelled {
+				assert.Empty(t, queue.ids())
+				if assert.Len(t, statuses, 1) {
+					assert.Equal(t, pb.GithubDeploymentState_cancelled.String(), statuses[0].Status)
+				}
+			} else {
+				// deployd reports the cancelled status once the deployment has stopped.
+				assert.Equal(t, []string{"123"}, queue.ids())
+				assert.Empty(t, statuses)
+			}
+		})
+	}
+}
+
+func TestCancelDeploymentOffline(t *testing.T) {
+	now := time.Now()
+	queue := &queueStore{}
+	s := testServer(queue)
+
+	queued(t, s, request("123", "dev", now, now.Add(time.Minute)))
+	queue.update("123", func(r *database.DeploymentRequest) {
+		r.Acknowledged = &now
+	})
+
+	err := s.CancelDeployment(context.Background(), "123", "dev")
+	assert.EqualError(t, err, "cluster 'dev' is offline")
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/navikt/deployment/pkg/hookd/database"
+	database_mapper "github.com/navikt/deployment/pkg/hookd/database/mapper"
 	"github.com/navikt/deployment/pkg/hookd/github"
 	"github.com/navikt/deployment/pkg/hookd/metrics"
 	log "github.com/sirupsen/logrus"
@@ -13,30 +16,49 @@ import (
 	"github.com/navikt/deployment/pkg/pb"
 )
 
+// Deployments older than this are not resumed by deployd after a restart,
+// as their rollout would have timed out long ago.
+var resumeWindow = time.Hour * 24
+
 type DeployServer interface {
 	pb.DeployServer
 	SendDeploymentRequest(ctx context.Context, deployment pb.DeploymentRequest) error
 	HandleDeploymentStatus(ctx context.Context, status pb.DeploymentStatus) error
+	SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func())
+	CancelDeployment(ctx context.Context, deploymentID, cluster string) error
 }
 
 type deployServer struct {
+	// lock must be held while accessing streams, and while flushing the request queue,
+	// so that queued requests are delivered before any new ones.
+	lock         sync.Mutex
 	streams      map[string]pb.Deploy_DeploymentsServer
+	instances    map[string]string
 	db           database.DeploymentStore
+	queue        database.DeploymentRequestStore
 	githubClient github.Client
+	ackTimeout   time.Duration
 	requests     chan pb.DeploymentRequest
 	statuses     chan pb.DeploymentStatus
+	subscribers  subscribers
 }
 
-func New(db database.DeploymentStore, githubClient github.Client) DeployServer {
+// New creates a deploy server. Deployment requests that are not acknowledged by deployd within ackTimeout are redelivered.
+func New(db database.DeploymentStore, queue database.DeploymentRequestStore, githubClient github.Client, ackTimeout time.Duration) DeployServer {
 	server := &deployServer{
 		streams:      make(map[string]pb.Deploy_DeploymentsServer),
+		instances:    make(map[string]string),
 		db:           db,
+		queue:        queue,
 		githubClient: githubClient,
+		ackTimeout:   ackTimeout,
 		requests:     make(chan pb.DeploymentRequest, 4096),
 		statuses:     make(chan pb.DeploymentStatus, 4096),
+		subscribers:  subscribers{channels: make(map[string]map[chan pb.DeploymentStatus]interface{})},
 	}
 
 	go server.githubLoop()
+	go server.deliveryLoop()
 
 	return server
 }
@@ -57,21 +79,29 @@ func (s *deployServer) reportOnlineClusters() {
 }
 
 func (s *deployServer) Deployments(opts *pb.GetDeploymentOpts, stream pb.Deploy_DeploymentsServer) error {
+	s.lock.Lock()
 	err := s.clusterOnline(opts.Cluster)
 	if err == nil {
+		s.lock.Unlock()
 		log.Warnf("Rejected connection from cluster '%s': already connected", opts.Cluster)
 		return fmt.Errorf("cluster already connected: %s", opts.Cluster)
 	}
 	s.streams[opts.Cluster] = stream
-	log.Infof("Connection opened from cluster '%s'", opts.Cluster)
+	s.instances[opts.Cluster] = opts.Instance
+	log.Infof("Connection opened from cluster '%s', instance '%s'", opts.Cluster, opts.Instance)
 	s.reportOnlineClusters()
+	s.flushQueue(opts.Cluster)
+	s.lock.Unlock()
 
 	// wait for disconnect
 	<-stream.Context().Done()
 
+	s.lock.Lock()
 	delete(s.streams, opts.Cluster)
+	delete(s.instances, opts.Cluster)
 	log.Warnf("Connection from cluster '%s' closed", opts.Cluster)
 	s.reportOnlineClusters()
+	s.lock.Unlock()
 
 	return nil
 }
@@ -79,3 +109,44 @@ func (s *deployServer) Deployments(opts *pb.GetDeploymentOpts, stream pb.Deploy_
 func (s *deployServer) ReportStatus(ctx context.Context, status *pb.DeploymentStatus) (*pb.ReportStatusOpts, error) {
 	return &pb.ReportStatusOpts{}, s.HandleDeploymentStatus(ctx, *status)
 }
+
+func (s *deployServer) Acknowledge(ctx context.Context, ack *pb.Acknowledgement) (*pb.AcknowledgeOpts, error) {
+	logger := log.WithFields(ack.LogFields())
+
+	err := s.queue.AcknowledgeDeploymentRequest(ctx, ack.GetDeliveryID(), ack.GetInstance())
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			// The request has expired in the meantime, and deployd will reject it anyway.
+			logger.Warnf("Acknowledgement received for unknown deployment request")
+			return &pb.AcknowledgeOpts{}, nil
+		}
+		return nil, fmt.Errorf("write acknowledgement to database: %s", err)
+	}
+
+	logger.Infof("Deployment request acknowledged by instance '%s'", ack.GetInstance())
+
+	return &pb.AcknowledgeOpts{}, nil
+}
+
+// UnfinishedDeployments returns deployment requests that deployd has acknowledged, but that have not yet reached
+// a final state. deployd calls this on startup to resume monitoring deployments interrupted by a restart.
+func (s *deployServer) UnfinishedDeployments(ctx context.Context, opts *pb.GetDeploymentOpts) (*pb.DeploymentRequests, error) {
+	unfinished, err := s.queue.UnfinishedDeploymentRequests(ctx, opts.GetCluster(), time.Now().Add(-resumeWindow))
+	if err != nil {
+		return nil, fmt.Errorf("retrieve unfinished deployment requests: %s", err)
+	}
+
+	requests := make([]*pb.DeploymentRequest, 0, len(unfinished))
+	for _, q := range unfinished {
+		request, err := database_mapper.PbDeploymentRequest(q)
+		if err != nil {
+			log.Errorf("Unable to decode queued deployment request %s: %s", q.ID, err)
+			continue
+		}
+		requests = append(requests, request)
+	}
+
+	log.Infof("Instance '%s' in cluster '%s' resumes %d unfinished deployments", opts.GetInstance(), opts.GetCluster(), len(requests))
+
+	return &pb.DeploymentRequests{Requests: requests}, nil
+}
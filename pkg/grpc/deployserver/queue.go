@@ -0,0 +1,200 @@
+package deployserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/database"
+	database_mapper "github.com/navikt/deployment/pkg/hookd/database/mapper"
+	"github.com/navikt/deployment/pkg/hookd/metrics"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+// How often to look for queued deployment requests that have passed their deadline,
+// or that must be redelivered because they were not acknowledged in time.
+var deliveryInterval = time.Second * 5
+
+// Persist a deployment request in the database until its deployment has finished.
+func (s *deployServer) enqueue(ctx context.Context, request pb.DeploymentRequest) error {
+	queued, err := database_mapper.DeploymentRequest(request)
+	if err != nil {
+		return err
+	}
+
+	return s.queue.QueueDeploymentRequest(ctx, *queued)
+}
+
+// Deliver all unacknowledged deployment requests for a cluster, in the order they were made.
+// This includes requests delivered over a previous connection but never acknowledged.
+// Requests that have passed their deadline are marked as errors instead.
+// Must be called with the lock held.
+func (s *deployServer) flushQueue(cluster string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	queued, err := s.queue.PendingDeploymentRequests(ctx, cluster)
+	cancel()
+
+	if err != nil {
+		log.Errorf("Unable to retrieve queued deployment requests for cluster '%s': %s", cluster, err)
+		return
+	}
+
+	if len(queued) == 0 {
+		return
+	}
+
+	log.Infof("Delivering %d queued deployment requests to cluster '%s'", len(queued), cluster)
+
+	for _, q := range queued {
+		if time.Now().After(q.Deadline) {
+			s.expire(q)
+			continue
+		}
+
+		request, err := database_mapper.PbDeploymentRequest(q)
+		if err != nil {
+			log.Errorf("Unable to decode queued deployment request %s: %s", q.ID, err)
+			continue
+		}
+
+		err = s.deliver(*request)
+		if err != nil {
+			// The stream is probably broken, so further requests stay queued until next reconnect.
+			log.WithFields(request.LogFields()).Errorf("Unable to deliver queued deployment request: %s", err)
+			return
+		}
+	}
+}
+
+// Resend a delivered deployment request that was not acknowledged in time.
+// If the cluster is offline, the request is left for flushQueue to deliver on reconnect.
+// Must be called with the lock held.
+func (s *deployServer) redeliver(q database.DeploymentRequest) {
+	if s.clusterOnline(q.Cluster) != nil {
+		return
+	}
+
+	request, err := database_mapper.PbDeploymentRequest(q)
+	if err != nil {
+		log.Errorf("Unable to decode queued deployment request %s: %s", q.ID, err)
+		return
+	}
+
+	logger := log.WithFields(request.LogFields())
+	logger.Warnf("Deployment request was not acknowledged within %s; redelivering", s.ackTimeout)
+
+	err = s.deliver(*request)
+	if err != nil {
+		logger.Errorf("Unable to redeliver deployment request: %s", err)
+		return
+	}
+
+	metrics.DeploymentRequestRedelivered(q.Cluster)
+}
+
+// Mark a queued deployment request as failed because it was not delivered in time, and remove it from the queue.
+// Must be called with the lock held.
+func (s *deployServer) expire(q database.DeploymentRequest) {
+	request, err := database_mapper.PbDeploymentRequest(q)
+	if err != nil {
+		log.Errorf("Unable to decode queued deployment request %s: %s", q.ID, err)
+		s.dequeue(q.ID)
+		return
+	}
+
+	logger := log.WithFields(request.LogFields())
+
+	var reason error
+	if q.Delivered == nil {
+		reason = fmt.Errorf("deployment request expired while cluster '%s' was offline", q.Cluster)
+	} else {
+		reason = fmt.Errorf("deployment request expired before it was acknowledged by cluster '%s'", q.Cluster)
+	}
+
+	logger.Warn(reason)
+
+	status := pb.NewErrorStatus(*request, reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	err = s.HandleDeploymentStatus(ctx, *status)
+	cancel()
+
+	if err != nil {
+		// Keep the request in the queue, so that expiry is attempted again later.
+		logger.Errorf("Unable to store deployment status: %s", err)
+		return
+	}
+
+	s.dequeue(q.ID)
+}
+
+func (s *deployServer) dequeue(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	err := s.queue.DeleteDeploymentRequest(ctx, id)
+	if err != nil {
+		log.Errorf("Unable to remove deployment request %s from queue: %s", id, err)
+	}
+}
+
+// Periodically expire queued deployment requests, so that their deployments
+// are not left hanging while the cluster remains offline, redeliver
+// requests that were not acknowledged in time, and remove requests
+// that are too old to be resumed.
+func (s *deployServer) deliveryLoop() {
+	for range time.NewTicker(deliveryInterval).C {
+		s.processQueue()
+	}
+}
+
+func (s *deployServer) processQueue() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	expired, err := s.queue.ExpiredDeploymentRequests(ctx, time.Now())
+	cancel()
+
+	if err != nil {
+		log.Errorf("Unable to retrieve expired deployment requests: %s", err)
+		return
+	}
+
+	s.lock.Lock()
+	for _, q := range expired {
+		s.expire(q)
+	}
+	s.lock.Unlock()
+
+	ctx, cancel = context.WithTimeout(context.Background(), requestTimeout)
+	unacknowledged, err := s.queue.UnacknowledgedDeploymentRequests(ctx, time.Now().Add(-s.ackTimeout))
+	cancel()
+
+	if err != nil {
+		log.Errorf("Unable to retrieve unacknowledged deployment requests: %s", err)
+		return
+	}
+
+	s.lock.Lock()
+	for _, q := range unacknowledged {
+		s.redeliver(q)
+	}
+	s.lock.Unlock()
+
+	s.removeStale()
+}
+
+// Remove acknowledged deployment requests that are too old to be resumed, in case their final status was never received.
+func (s *deployServer) removeStale() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	removed, err := s.queue.DeleteAcknowledgedDeploymentRequests(ctx, time.Now().Add(-resumeWindow))
+	if err != nil {
+		log.Errorf("Unable to remove stale deployment requests: %s", err)
+		return
+	}
+
+	if removed > 0 {
+		log.Infof("Removed %d deployment requests older than %s", removed, resumeWindow)
+	}
+}
@@ -0,0 +1,281 @@
+package deployserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// queueStore keeps deployment requests in memory, mirroring the queries of the database implementation.
+type queueStore struct {
+	lock     sync.Mutex
+	requests []database.DeploymentRequest
+}
+
+func (q *queueStore) find(filter func(r database.DeploymentRequest) bool) []database.DeploymentRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	requests := make([]database.DeploymentRequest, 0)
+	for _, r := range q.requests {
+		if filter(r) {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+func (q *queueStore) update(id string, fn func(r *database.DeploymentRequest)) bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for i := range q.requests {
+		if q.requests[i].ID == id {
+			fn(&q.requests[i])
+			return true
+		}
+	}
+	return false
+}
+
+func (q *queueStore) ids() []string {
+	ids := make([]string, 0)
+	for _, r := range q.find(func(r database.DeploymentRequest) bool { return true }) {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func (q *queueStore) QueueDeploymentRequest(ctx context.Context, request database.DeploymentRequest) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.requests = append(q.requests, request)
+	return nil
+}
+
+func (q *queueStore) PendingDeploymentRequests(ctx context.Context, cluster string) ([]database.DeploymentRequest, error) {
+	return q.find(func(r database.DeploymentRequest) bool {
+		return r.Cluster == cluster && r.Acknowledged == nil
+	}), nil
+}
+
+func (q *queueStore) UnacknowledgedDeploymentRequests(ctx context.Context, deliveredBefore time.Time) ([]database.DeploymentRequest, error) {
+	return q.find(func(r database.DeploymentRequest) bool {
+		return r.Delivered != nil && r.Delivered.Before(deliveredBefore) && r.Acknowledged == nil
+	}), nil
+}
+
+func (q *queueStore) ExpiredDeploymentRequests(ctx context.Context, deadline time.Time) ([]database.DeploymentRequest, error) {
+	return q.find(func(r database.DeploymentRequest) bool {
+		return r.Deadline.Before(deadline) && r.Acknowledged == nil
+	}), nil
+}
+
+func (q *queueStore) MarkDeploymentRequestDelivered(ctx context.Context, id, instance string) error {
+	q.update(id, func(r *database.DeploymentRequest) {
+		now := time.Now()
+		r.Delivered = &now
+		r.Instance = &instance
+	})
+	return nil
+}
+
+func (q *queueStore) AcknowledgeDeploymentRequest(ctx context.Context, id, instance string) error {
+	found := q.update(id, func(r *database.DeploymentRequest) {
+		now := time.Now()
+		r.Acknowledged = &now
+		r.Instance = &instance
+	})
+	if !found {
+		return database.ErrNotFound
+	}
+	return nil
+}
+
+func (q *queueStore) DeleteDeploymentRequest(ctx context.Context, id string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i := range q.requests {
+		if q.requests[i].ID == id {
+			q.requests = append(q.requests[:i], q.requests[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (q *queueStore) DeleteAcknowledgedDeploymentRequests(ctx context.Context, createdBefore time.Time) (int64, error) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	kept := make([]database.DeploymentRequest, 0)
+	for _, r := range q.requests {
+		if r.Acknowledged == nil || !r.Created.Before(createdBefore) {
+			kept = append(kept, r)
+		}
+	}
+	removed := len(q.requests) - len(kept)
+	q.requests = kept
+	return int64(removed), nil
+}
+
+func (q *queueStore) UnfinishedDeploymentRequests(ctx context.Context, cluster string, createdAfter time.Time) ([]database.DeploymentRequest, error) {
+	return q.find(func(r database.DeploymentRequest) bool {
+		return r.Cluster == cluster && r.Created.After(createdAfter) && r.Acknowledged != nil
+	}), nil
+}
+
+type statusStore struct {
+	database.DeploymentStore
+	statuses []database.DeploymentStatus
+}
+
+func (s *statusStore) WriteDeploymentStatus(ctx context.Context, status database.DeploymentStatus) error {
+	s.statuses = append(s.statuses, status)
+	return nil
+}
+
+type requestStream struct {
+	pb.Deploy_DeploymentsServer
+	sent []string
+}
+
+func (s *requestStream) Send(request *pb.DeploymentRequest) error {
+	s.sent = append(s.sent, request.GetDeliveryID())
+	return nil
+}
+
+func testServer(queue *queueStore) *deployServer {
+	return &deployServer{
+		streams:     make(map[string]pb.Deploy_DeploymentsServer),
+		instances:   make(map[string]string),
+		db:          &statusStore{},
+		queue:       queue,
+		ackTimeout:  time.Minute,
+		requests:    make(chan pb.DeploymentRequest, 16),
+		statuses:    make(chan pb.DeploymentStatus, 16),
+		subscribers: subscribers{channels: make(map[string]map[chan pb.DeploymentStatus]interface{})},
+	}
+}
+
+func connect(s *deployServer, cluster string) *requestStream {
+	stream := &requestStream{}
+	s.streams[cluster] = stream
+	s.instances[cluster] = cluster + "-instance"
+	return stream
+}
+
+func request(id, cluster string, created, deadline time.Time) pb.DeploymentRequest {
+	return pb.DeploymentRequest{
+		DeliveryID: id,
+		Cluster:    cluster,
+		Time:       pb.TimeAsTimestamp(created),
+		Deadline:   deadline.Unix(),
+	}
+}
+
+func queued(t *testing.T, s *deployServer, requests ...pb.DeploymentRequest) {
+	for _, r := range requests {
+		err := s.enqueue(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSendDeploymentRequest(t *testing.T) {
+	now := time.Now()
+	queue := &queueStore{}
+	s := testServer(queue)
+
+	err := s.SendDeploymentRequest(context.Background(), request("offline", "dev", now, now.Add(time.Minute)))
+	assert.NoError(t, err)
+
+	stream := connect(s, "dev")
+
+	dryRun := request("dryrun", "dev", now, now.Add(time.Minute))
+	dryRun.DryRun = true
+	err = s.SendDeploymentRequest(context.Background(), dryRun)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"offline", "dryrun"}, queue.ids())
+	assert.Equal(t, []string{"dryrun"}, stream.sent)
+
+	requests := queue.find(func(r database.DeploymentRequest) bool { return true })
+	assert.Nil(t, requests[0].Delivered)
+	assert.NotNil(t, requests[1].Delivered)
+	assert.Equal(t, "dev-instance", *requests[1].Instance)
+
+	// Dry runs are not synchronized to GitHub.
+	assert.Len(t, s.requests, 1)
+	assert.Equal(t, "offline", (<-s.requests).DeliveryID)
+}
+
+func TestFlushQueue(t *testing.T) {
+	now := time.Now()
+	queue := &queueStore{}
+	s := testServer(queue)
+
+	queued(t, s,
+		request("first", "dev", now.Add(-2*time.Minute), now.Add(time.Minute)),
+		request("expired", "dev", now.Add(-90*time.Second), now.Add(-time.Second)),
+		request("other-cluster", "prod", now.Add(-time.Minute), now.Add(time.Minute)),
+		request("acknowledged", "dev", now.Add(-time.Minute), now.Add(time.Minute)),
+		request("second", "dev", now, now.Add(time.Minute)),
+	)
+	queue.AcknowledgeDeploymentRequest(context.Background(), "acknowledged", "dev-instance")
+
+	stream := connect(s, "dev")
+	s.flushQueue("dev")
+
+	assert.Equal(t, []string{"first", "second"}, stream.sent)
+	assert.Equal(t, []string{"first", "other-cluster", "acknowledged", "second"}, queue.ids())
+
+	statuses := s.db.(*statusStore).statuses
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, "expired", statuses[0].DeploymentID)
+		assert.Equal(t, pb.GithubDeploymentState_error.String(), statuses[0].Status)
+		assert.Contains(t, statuses[0].Message, "expired while cluster 'dev' was offline")
+	}
+}
+
+func TestProcessQueue(t *testing.T) {
+	now := time.Now()
+	queue := &queueStore{}
+	s := testServer(queue)
+	stream := connect(s, "dev")
+
+	queued(t, s,
+		request("stale", "dev", now.Add(-resumeWindow-time.Minute), now.Add(-resumeWindow)),
+		request("expired", "prod", now.Add(-time.Hour), now.Add(-time.Second)),
+		request("unacknowledged", "dev", now.Add(-2*time.Minute), now.Add(time.Hour)),
+		request("unacknowledged-offline", "prod", now.Add(-2*time.Minute), now.Add(time.Hour)),
+		request("recent", "dev", now.Add(-time.Minute), now.Add(time.Hour)),
+		request("running", "dev", now.Add(-time.Minute), now.Add(time.Hour)),
+	)
+
+	delivered := now.Add(-2 * time.Minute)
+	for _, id := range []string{"stale", "unacknowledged", "unacknowledged-offline", "running"} {
+		queue.update(id, func(r *database.DeploymentRequest) {
+			r.Delivered = &delivered
+		})
+	}
+	queue.MarkDeploymentRequestDelivered(context.Background(), "recent", "dev-instance")
+	queue.AcknowledgeDeploymentRequest(context.Background(), "stale", "dev-instance")
+	queue.AcknowledgeDeploymentRequest(context.Background(), "running", "dev-instance")
+
+	s.processQueue()
+
+	assert.Equal(t, []string{"unacknowledged"}, stream.sent)
+	assert.Equal(t, []string{"unacknowledged", "unacknowledged-offline", "recent", "running"}, queue.ids())
+
+	statuses := s.db.(*statusStore).statuses
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, "expired", statuses[0].DeploymentID)
+		assert.Equal(t, pb.GithubDeploymentState_error.String(), statuses[0].Status)
+	}
+}
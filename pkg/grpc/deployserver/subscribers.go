@@ -0,0 +1,63 @@
+package deployserver
+
+import (
+	"sync"
+
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+// Buffer size of each subscription channel.
+// Statuses are dropped for subscribers that do not keep up.
+const subscriptionBuffer = 32
+
+// subscribers keeps track of clients listening for status updates of specific deployments.
+type subscribers struct {
+	lock     sync.Mutex
+	channels map[string]map[chan pb.DeploymentStatus]interface{}
+}
+
+func (s *subscribers) subscribe(deploymentID string) chan pb.DeploymentStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ch := make(chan pb.DeploymentStatus, subscriptionBuffer)
+	if s.channels[deploymentID] == nil {
+		s.channels[deploymentID] = make(map[chan pb.DeploymentStatus]interface{})
+	}
+	s.channels[deploymentID][ch] = new(interface{})
+
+	return ch
+}
+
+func (s *subscribers) unsubscribe(deploymentID string, ch chan pb.DeploymentStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.channels[deploymentID], ch)
+	if len(s.channels[deploymentID]) == 0 {
+		delete(s.channels, deploymentID)
+	}
+}
+
+func (s *subscribers) publish(status pb.DeploymentStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for ch := range s.channels[status.GetDeliveryID()] {
+		select {
+		case ch <- status:
+		default:
+			log.WithFields(status.LogFields()).Warnf("Status subscriber is not keeping up; dropping status")
+		}
+	}
+}
+
+// SubscribeDeploymentStatus returns a channel receiving every status written for the given deployment,
+// and a function that must be called to cancel the subscription.
+func (s *deployServer) SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func()) {
+	ch := s.subscribers.subscribe(deploymentID)
+	return ch, func() {
+		s.subscribers.unsubscribe(deploymentID, ch)
+	}
+}
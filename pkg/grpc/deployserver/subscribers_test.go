@@ -0,0 +1,43 @@
+package deployserver
+
+import (
+	"testing"
+
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribers(t *testing.T) {
+	subs := subscribers{channels: make(map[string]map[chan pb.DeploymentStatus]interface{})}
+
+	first := subs.subscribe("123")
+	second := subs.subscribe("123")
+	other := subs.subscribe("456")
+
+	subs.publish(pb.DeploymentStatus{DeliveryID: "123", Description: "applying resources"})
+
+	assert.Equal(t, "applying resources", (<-first).Description)
+	assert.Equal(t, "applying resources", (<-second).Description)
+	assert.Len(t, other, 0)
+
+	subs.unsubscribe("123", first)
+	subs.publish(pb.DeploymentStatus{DeliveryID: "123", Description: "all resources deployed"})
+
+	assert.Len(t, first, 0)
+	assert.Equal(t, "all resources deployed", (<-second).Description)
+
+	subs.unsubscribe("123", second)
+	subs.unsubscribe("456", other)
+	assert.Empty(t, subs.channels)
+}
+
+func TestSubscribersDropStatusesForSlowSubscribers(t *testing.T) {
+	subs := subscribers{channels: make(map[string]map[chan pb.DeploymentStatus]interface{})}
+	ch := subs.subscribe("123")
+
+	for i := 0; i < subscriptionBuffer+5; i++ {
+		subs.publish(pb.DeploymentStatus{DeliveryID: "123"})
+	}
+
+	assert.Len(t, ch, subscriptionBuffer)
+}
@@ -11,8 +11,10 @@ import (
 	gh "github.com/google/go-github/v27/github"
 	"github.com/navikt/deployment/pkg/azure/discovery"
 	"github.com/navikt/deployment/pkg/azure/graphapi"
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
 	api_v1_apikey "github.com/navikt/deployment/pkg/hookd/api/v1/apikey"
 	api_v1_deploy "github.com/navikt/deployment/pkg/hookd/api/v1/deploy"
+	api_v1_deployments "github.com/navikt/deployment/pkg/hookd/api/v1/deployments"
 	api_v1_provision "github.com/navikt/deployment/pkg/hookd/api/v1/provision"
 	api_v1_status "github.com/navikt/deployment/pkg/hookd/api/v1/status"
 	api_v1_teams "github.com/navikt/deployment/pkg/hookd/api/v1/teams"
@@ -42,10 +44,25 @@ type Config struct {
 	MetricsPath                 string
 	OAuthKeyValidatorMiddleware Middleware
 	ProvisionKey                []byte
+	RolloutTimeout              api_v1.RolloutTimeout
 	TeamClient                  graphapi.Client
 	TeamRepositoryStorage       database.RepositoryTeamStore
 }
 
+// Skip OAuth validation for requests carrying a HMAC signature, which are authenticated by the handler instead.
+func unlessSigned(oauth Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		validated := oauth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.Header.Get(api_v1.SignatureHeader)) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			validated.ServeHTTP(w, r)
+		})
+	}
+}
+
 func New(cfg Config) chi.Router {
 
 	prometheusMiddleware := middleware.PrometheusMiddleware("hookd")
@@ -56,6 +73,13 @@ func New(cfg Config) chi.Router {
 		DeployServer:    cfg.DeployServer,
 		Clusters:        cfg.Clusters,
 		DeploymentStore: cfg.DeploymentStore,
+		RolloutTimeout:  cfg.RolloutTimeout,
+	}
+
+	deploymentsHandler := &api_v1_deployments.DeploymentsHandler{
+		APIKeyStorage:   cfg.ApiKeyStore,
+		DeployServer:    cfg.DeployServer,
+		DeploymentStore: cfg.DeploymentStore,
 	}
 
 	teamsHandler := &api_v1_teams.TeamsHandler{
@@ -68,6 +92,8 @@ func New(cfg Config) chi.Router {
 
 	statusHandler := &api_v1_status.StatusHandler{
 		APIKeyStorage:   cfg.ApiKeyStore,
+		BaseURL:         cfg.BaseURL,
+		DeployServer:    cfg.DeployServer,
 		DeploymentStore: cfg.DeploymentStore,
 	}
 
@@ -83,6 +109,7 @@ func New(cfg Config) chi.Router {
 	}
 	for _, code := range api_v1_status.StatusCodes {
 		prometheusMiddleware.Initialize("/api/v1/status", http.MethodPost, code)
+		prometheusMiddleware.Initialize("/api/v1/status/stream", http.MethodPost, code)
 	}
 	for _, code := range api_v1_provision.StatusCodes {
 		prometheusMiddleware.Initialize("/api/v1/provision", http.MethodPost, code)
@@ -111,32 +138,47 @@ func New(cfg Config) chi.Router {
 	router.Route("/api/v1", func(r chi.Router) {
 		r.Use(
 			chi_middleware.AllowContentType("application/json"),
-			chi_middleware.Timeout(requestTimeout),
 		)
-		if cfg.OAuthKeyValidatorMiddleware != nil {
-			r.Route("/apikey", func(r chi.Router) {
-				r.Use(cfg.OAuthKeyValidatorMiddleware)
-				r.Get("/", apikeyHandler.GetApiKeys)              // -> apikey til alle teams brukeren er autorisert for å se
-				r.Get("/{team}", apikeyHandler.GetTeamApiKey)     // -> apikey til dette spesifikke teamet
-				r.Post("/{team}", apikeyHandler.RotateTeamApiKey) // -> rotate key (Validere at brukeren er owner av gruppa som eier keyen)
-			})
-			r.Route("/teams", func(r chi.Router) {
-				r.Use(cfg.OAuthKeyValidatorMiddleware)
-				r.Get("/", teamsHandler.ServeHTTP) // -> ID og navn (Liste over teams brukeren har tilgang til)
-			})
-		} else {
-			log.Error("Refusing to set up team API key retrieval without OAuth middleware; try configuring --azure-*")
-			log.Error("Note: /api/v1/apikey will be unavailable")
-			log.Error("Note: /api/v1/teams will be unavailable")
-		}
-		r.Post("/deploy", deploymentHandler.ServeHTTP)
-		r.Post("/status", statusHandler.ServeHTTP)
-		if len(cfg.ProvisionKey) == 0 {
-			log.Error("Refusing to set up team API provisioning endpoint without pre-shared secret; try using --provision-key")
-			log.Error("Note: /api/v1/provision will be unavailable")
-		} else {
-			r.Post("/provision", provisionHandler.ServeHTTP)
-		}
+
+		// Status streams are long-lived, and must not be subject to the request timeout.
+		r.Post("/status/stream", statusHandler.Stream)
+
+		r.Group(func(r chi.Router) {
+			r.Use(
+				chi_middleware.Timeout(requestTimeout),
+			)
+			if cfg.OAuthKeyValidatorMiddleware != nil {
+				r.Route("/apikey", func(r chi.Router) {
+					r.Use(cfg.OAuthKeyValidatorMiddleware)
+					r.Get("/", apikeyHandler.GetApiKeys)              // -> apikey til alle teams brukeren er autorisert for å se
+					r.Get("/{team}", apikeyHandler.GetTeamApiKey)     // -> apikey til dette spesifikke teamet
+					r.Post("/{team}", apikeyHandler.RotateTeamApiKey) // -> rotate key (Validere at brukeren er owner av gruppa som eier keyen)
+				})
+				r.Route("/teams", func(r chi.Router) {
+					r.Use(cfg.OAuthKeyValidatorMiddleware)
+					r.Get("/", teamsHandler.ServeHTTP) // -> ID og navn (Liste over teams brukeren har tilgang til)
+				})
+				r.Route("/deployments", func(r chi.Router) {
+					r.With(cfg.OAuthKeyValidatorMiddleware).Get("/", deploymentsHandler.List)    // -> deployments matching query parameters, newest first
+					r.With(cfg.OAuthKeyValidatorMiddleware).Get("/{id}", deploymentsHandler.Get) // -> single deployment with complete status timeline
+					r.With(unlessSigned(cfg.OAuthKeyValidatorMiddleware)).Post("/{id}/cancel", deploymentsHandler.Cancel)
+				})
+			} else {
+				log.Error("Refusing to set up team API key retrieval without OAuth middleware; try configuring --azure-*")
+				log.Error("Note: /api/v1/apikey will be unavailable")
+				log.Error("Note: /api/v1/teams will be unavailable")
+				log.Error("Note: /api/v1/deployments will be unavailable, except for HMAC signed cancellations")
+				r.Post("/deployments/{id}/cancel", deploymentsHandler.Cancel)
+			}
+			r.Post("/deploy", deploymentHandler.ServeHTTP)
+			r.Post("/status", statusHandler.ServeHTTP)
+			if len(cfg.ProvisionKey) == 0 {
+				log.Error("Refusing to set up team API provisioning endpoint without pre-shared secret; try using --provision-key")
+				log.Error("Note: /api/v1/provision will be unavailable")
+			} else {
+				r.Post("/provision", provisionHandler.ServeHTTP)
+			}
+		})
 	})
 
 	return router
@@ -43,6 +43,10 @@ func DeploymentRequestMessage(r *DeploymentRequest, deliveryID string) (*types.D
 		Cluster:    r.Cluster,
 		Time:       types.TimeAsTimestamp(now),
 		Deadline:   now.Add(ttl).Unix(),
+		DryRun:     r.DryRun,
+		Rollback:   r.Rollback,
+		Inventory:  r.Inventory,
+		Prune:      r.Prune,
 	}, nil
 }
 
@@ -28,6 +28,7 @@ type DeploymentHandler struct {
 	DeploymentStore database.DeploymentStore
 	BaseURL         string
 	Clusters        api_v1.ClusterList
+	RolloutTimeout  api_v1.RolloutTimeout
 }
 
 type DeploymentRequest struct {
@@ -38,13 +39,22 @@ type DeploymentRequest struct {
 	Owner       string          `json:"owner,omitempty"`
 	Repository  string          `json:"repository,omitempty"`
 	Ref         string          `json:"ref,omitempty"`
-	Timestamp   int64           `json:"timestamp"`
+	Deployer    string          `json:"deployer,omitempty"`
+	DryRun      bool            `json:"dryRun,omitempty"`
+	Rollback    bool            `json:"rollback,omitempty"`
+	Inventory   string          `json:"inventory,omitempty"`
+	Prune       bool            `json:"prune,omitempty"`
+	// How long to monitor the rollout of the deployed resources, e.g. "15m". Empty means the server default.
+	RolloutTimeout string `json:"rolloutTimeout,omitempty"`
+	Timestamp      int64  `json:"timestamp"`
 }
 
 type DeploymentResponse struct {
 	Message       string `json:"message,omitempty"`
 	CorrelationID string `json:"correlationID,omitempty"`
 	LogURL        string `json:"logURL,omitempty"`
+	// The rollout timeout used for this deployment, which is the server default if none was requested.
+	RolloutTimeout string `json:"rolloutTimeout,omitempty"`
 }
 
 func (r *DeploymentResponse) render(w io.Writer) {
@@ -77,9 +87,29 @@ func (r *DeploymentRequest) validate() error {
 		return fmt.Errorf("resources must contain at least one Kubernetes resource")
 	}
 
+	if len(r.RolloutTimeout) > 0 {
+		_, err = time.ParseDuration(r.RolloutTimeout)
+		if err != nil {
+			return fmt.Errorf("rollout timeout must be a duration such as '15m'")
+		}
+	}
+
 	return nil
 }
 
+// Resolve the rollout timeout requested by the user, if any, against the server-side bounds.
+func (r *DeploymentRequest) rolloutTimeout(bounds api_v1.RolloutTimeout) (time.Duration, error) {
+	var requested time.Duration
+	if len(r.RolloutTimeout) > 0 {
+		var err error
+		requested, err = time.ParseDuration(r.RolloutTimeout)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return bounds.Effective(requested)
+}
+
 func (r *DeploymentRequest) GithubDeploymentRequest() gh.DeploymentRequest {
 	requiredContexts := make([]string, 0)
 	return gh.DeploymentRequest{
@@ -94,6 +124,7 @@ func (r *DeploymentRequest) GithubDeploymentRequest() gh.DeploymentRequest {
 func (h *DeploymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var err error
 	var deploymentResponse DeploymentResponse
+	var rolloutTimeout time.Duration
 
 	fields := middleware.RequestLogFields(r)
 	logger := log.WithFields(fields)
@@ -158,6 +189,9 @@ func (h *DeploymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err == nil {
 		err = h.Clusters.Contains(deploymentRequest.Cluster)
 	}
+	if err == nil {
+		rolloutTimeout, err = deploymentRequest.rolloutTimeout(h.RolloutTimeout)
+	}
 
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -206,11 +240,28 @@ func (h *DeploymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		logger.Errorf("unable to create deployment message: %s", err)
 		return
 	}
+	deployMsg.RolloutTimeoutSeconds = int64(rolloutTimeout / time.Second)
 
 	deployment := database.Deployment{
-		ID:      requestID.String(),
-		Team:    deploymentRequest.Team,
-		Created: time.Now(),
+		ID:          requestID.String(),
+		Team:        deploymentRequest.Team,
+		Created:     time.Now(),
+		Cluster:     &deploymentRequest.Cluster,
+		Environment: &deploymentRequest.Environment,
+		Ref:         &deploymentRequest.Ref,
+		DryRun:      deploymentRequest.DryRun,
+	}
+
+	if len(deploymentRequest.Deployer) > 0 {
+		deployment.Deployer = &deploymentRequest.Deployer
+	}
+
+	// Record the repository name up front, so that deployments can be looked up
+	// by repository even if they are never synchronized to GitHub.
+	repository := deployMsg.GetDeployment().GetRepository()
+	if repository.Valid() {
+		fullName := repository.FullName()
+		deployment.GitHubRepository = &fullName
 	}
 
 	err = h.DeploymentStore.WriteDeployment(r.Context(), deployment)
@@ -245,6 +296,9 @@ func (h *DeploymentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusCreated)
 	deploymentResponse.Message = "deployment request accepted and dispatched"
+	if rolloutTimeout > 0 {
+		deploymentResponse.RolloutTimeout = rolloutTimeout.String()
+	}
 	deploymentResponse.render(w)
 
 	logger.Info("Deployment request processed successfully")
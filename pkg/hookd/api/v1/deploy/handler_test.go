@@ -56,6 +56,14 @@ func (b *borker) HandleDeploymentStatus(ctx context.Context, status pb.Deploymen
 	return nil
 }
 
+func (b *borker) SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func()) {
+	return make(chan pb.DeploymentStatus), func() {}
+}
+
+func (b *borker) CancelDeployment(ctx context.Context, deploymentID, cluster string) error {
+	return nil
+}
+
 type db struct{}
 
 func (db *db) ApiKeys(ctx context.Context, team string) (database.ApiKeys, error) {
@@ -80,6 +88,14 @@ func (db *db) Deployment(ctx context.Context, id string) (*database.Deployment,
 	return nil, nil
 }
 
+func (db *db) LatestDeployment(ctx context.Context, team, repository string) (*database.Deployment, error) {
+	return nil, nil
+}
+
+func (db *db) Deployments(ctx context.Context, filter database.DeploymentFilter) ([]database.DeploymentSummary, error) {
+	return nil, nil
+}
+
 func (db *db) WriteDeployment(ctx context.Context, deployment database.Deployment) error {
 	switch deployment.Team {
 	case "database_unavailable":
@@ -111,6 +127,14 @@ func (b *borker) ReportStatus(ctx context.Context, status *pb.DeploymentStatus)
 	return nil, nil
 }
 
+func (b *borker) Acknowledge(ctx context.Context, ack *pb.Acknowledgement) (*pb.AcknowledgeOpts, error) {
+	return nil, nil
+}
+
+func (b *borker) UnfinishedDeployments(ctx context.Context, opts *pb.GetDeploymentOpts) (*pb.DeploymentRequests, error) {
+	return nil, nil
+}
+
 func (b *borker) Queue(request *pb.DeploymentRequest) error {
 	return nil
 }
@@ -129,6 +153,7 @@ func testResponse(t *testing.T, recorder *httptest.ResponseRecorder, response re
 	assert.NoError(t, err)
 	assert.Equal(t, response.StatusCode, recorder.Code)
 	assert.Equal(t, response.Body.Message, decodedBody.Message)
+	assert.Equal(t, response.Body.RolloutTimeout, decodedBody.RolloutTimeout)
 	assert.NotEmpty(t, decodedBody.CorrelationID)
 }
 
@@ -172,6 +197,11 @@ func subTest(t *testing.T, name string) {
 		DeploymentStore: apiKeyStore,
 		Clusters:        validClusters,
 		MetricsPath:     "/metrics",
+		RolloutTimeout: api_v1.RolloutTimeout{
+			Default: 30 * time.Minute,
+			Min:     time.Minute,
+			Max:     time.Hour,
+		},
 	})
 
 	handler.ServeHTTP(recorder, request)
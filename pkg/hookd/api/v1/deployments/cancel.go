@@ -0,0 +1,192 @@
+package api_v1_deployments
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/hookd/middleware"
+	"github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+// CancelRequest is the body of HMAC signed cancellation requests.
+// The deployment ID must match the one in the URL, so that a signature cannot be reused for another deployment.
+// Requests authenticated with OAuth need no body.
+type CancelRequest struct {
+	DeploymentID string           `json:"deploymentID"`
+	Team         string           `json:"team"`
+	Timestamp    api_v1.Timestamp `json:"timestamp"`
+}
+
+type CancelResponse struct {
+	Message string `json:"message"`
+}
+
+func (r *CancelRequest) validate() error {
+	if len(r.DeploymentID) == 0 {
+		return fmt.Errorf("no deployment ID specified")
+	}
+
+	if len(r.Team) == 0 {
+		return fmt.Errorf("no team specified")
+	}
+
+	return r.Timestamp.Validate()
+}
+
+// Check that the request is signed with one of the team's API keys, and refers to the given deployment.
+func (h *DeploymentsHandler) authenticateHMAC(r *http.Request, id, team string) (int, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("unable to read request body: %s", err)
+	}
+
+	signature, err := hex.DecodeString(r.Header.Get(api_v1.SignatureHeader))
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("HMAC digest must be hex encoded")
+	}
+
+	cancelRequest := &CancelRequest{}
+	err = json.Unmarshal(data, cancelRequest)
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("unable to unmarshal request body: %s", err)
+	}
+
+	err = cancelRequest.validate()
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("invalid cancel request: %s", err)
+	}
+
+	if cancelRequest.DeploymentID != id {
+		return http.StatusBadRequest, fmt.Errorf("invalid cancel request: deployment ID does not match the URL")
+	}
+
+	if cancelRequest.Team != team {
+		return http.StatusForbidden, fmt.Errorf("%s: deployment belongs to another team", api_v1.FailedAuthenticationMsg)
+	}
+
+	apiKeys, err := h.APIKeyStorage.ApiKeys(r.Context(), team)
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			return http.StatusForbidden, fmt.Errorf("%s: %s", api_v1.FailedAuthenticationMsg, err)
+		}
+		return http.StatusBadGateway, fmt.Errorf("unable to fetch team apikey from storage: %s", err)
+	}
+
+	err = api_v1.ValidateAnyMAC(data, signature, apiKeys.Valid().Keys())
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+
+	return http.StatusOK, nil
+}
+
+// Check that the user is a member of the group owning the team's API keys.
+func (h *DeploymentsHandler) authenticateOAuth(r *http.Request, team string) (int, error) {
+	groups, err := api_v1.GroupClaims(r.Context())
+	if err != nil {
+		return http.StatusUnauthorized, fmt.Errorf("%s: %s", api_v1.FailedAuthenticationMsg, err)
+	}
+
+	apiKeys, err := h.APIKeyStorage.ApiKeys(r.Context(), team)
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			return http.StatusForbidden, fmt.Errorf("%s: %s", api_v1.FailedAuthenticationMsg, err)
+		}
+		return http.StatusBadGateway, fmt.Errorf("unable to fetch team apikey from storage: %s", err)
+	}
+
+	for _, apiKey := range apiKeys {
+		for _, group := range groups {
+			if group == apiKey.GroupId {
+				return http.StatusOK, nil
+			}
+		}
+	}
+
+	return http.StatusForbidden, fmt.Errorf("%s: not a member of team %s", api_v1.FailedAuthenticationMsg, team)
+}
+
+// Cancel a deployment that has not yet reached a final state.
+// Requests are either HMAC signed with the team's API key, or authenticated with OAuth by a member of the team.
+func (h *DeploymentsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	var response CancelResponse
+
+	fields := middleware.RequestLogFields(r)
+	logger := log.WithFields(fields)
+
+	id := chi.URLParam(r, "id")
+	logger = logger.WithField(pb.LogFieldDeploymentID, id)
+
+	fail := func(code int, message string, err error) {
+		w.WriteHeader(code)
+		response.Message = message
+		render.JSON(w, r, response)
+		logger.Errorf("%s: %s", message, err)
+	}
+
+	dbDeployment, err := h.DeploymentStore.Deployment(r.Context(), id)
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			fail(http.StatusNotFound, "deployment not found", err)
+			return
+		}
+		fail(http.StatusServiceUnavailable, "unable to retrieve deployment; database is unavailable", err)
+		return
+	}
+
+	logger = logger.WithField(pb.LogFieldTeam, dbDeployment.Team)
+
+	var code int
+	if len(r.Header.Get(api_v1.SignatureHeader)) > 0 {
+		code, err = h.authenticateHMAC(r, id, dbDeployment.Team)
+	} else {
+		code, err = h.authenticateOAuth(r, dbDeployment.Team)
+	}
+	if err != nil {
+		message := api_v1.FailedAuthenticationMsg
+		if code == http.StatusBadRequest {
+			message = err.Error()
+		}
+		fail(code, message, err)
+		return
+	}
+
+	statuses, err := h.DeploymentStore.DeploymentStatus(r.Context(), id)
+	if err != nil && !database.IsErrNotFound(err) {
+		fail(http.StatusServiceUnavailable, "unable to retrieve deployment status; database is unavailable", err)
+		return
+	}
+
+	// Statuses are ordered by newest first.
+	if len(statuses) > 0 {
+		value, ok := pb.GithubDeploymentState_value[statuses[0].Status]
+		if ok && pb.GithubDeploymentState(value).Finished() {
+			fail(http.StatusConflict, fmt.Sprintf("deployment has already finished with state '%s'", statuses[0].Status), fmt.Errorf("not cancelling"))
+			return
+		}
+	}
+
+	if dbDeployment.Cluster == nil {
+		fail(http.StatusConflict, "deployment cluster is unknown", fmt.Errorf("not cancelling"))
+		return
+	}
+
+	err = h.DeployServer.CancelDeployment(r.Context(), id, *dbDeployment.Cluster)
+	if err != nil {
+		fail(http.StatusServiceUnavailable, "unable to cancel deployment", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	response.Message = "deployment cancellation requested"
+	render.JSON(w, r, response)
+	logger.Infof("Deployment cancellation requested")
+}
@@ -0,0 +1,342 @@
+package api_v1_deployments
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/navikt/deployment/pkg/grpc/deployserver"
+	api_v1 "github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/hookd/middleware"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+type DeploymentsHandler struct {
+	APIKeyStorage   database.ApiKeyStore
+	DeployServer    deployserver.DeployServer
+	DeploymentStore database.DeploymentStore
+}
+
+// Deployment as presented to users. The deployer is reported by the deploying client, and is not verified.
+type Deployment struct {
+	ID          string    `json:"id"`
+	Team        string    `json:"team"`
+	Cluster     string    `json:"cluster,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Repository  string    `json:"repository,omitempty"`
+	Ref         string    `json:"ref,omitempty"`
+	Deployer    string    `json:"deployer,omitempty"`
+	GitHubID    int       `json:"githubID,omitempty"`
+	State       string    `json:"state,omitempty"`
+	DryRun      bool      `json:"dryRun,omitempty"`
+	Created     time.Time `json:"created"`
+}
+
+type Status struct {
+	ID        string                  `json:"id"`
+	Status    string                  `json:"status"`
+	Message   string                  `json:"message"`
+	Diff      string                  `json:"diff,omitempty"`
+	Resources []api_v1.ResourceStatus `json:"resources,omitempty"`
+	Created   time.Time               `json:"created"`
+}
+
+type ListResponse struct {
+	Message     string       `json:"message,omitempty"`
+	Deployments []Deployment `json:"deployments,omitempty"`
+	NextCursor  string       `json:"nextCursor,omitempty"`
+}
+
+type DetailResponse struct {
+	Message    string      `json:"message,omitempty"`
+	Deployment *Deployment `json:"deployment,omitempty"`
+	Statuses   []Status    `json:"statuses,omitempty"`
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func deployment(d database.Deployment, state *string) Deployment {
+	deployment := Deployment{
+		ID:          d.ID,
+		Team:        d.Team,
+		Cluster:     str(d.Cluster),
+		Environment: str(d.Environment),
+		Repository:  str(d.GitHubRepository),
+		Ref:         str(d.Ref),
+		Deployer:    str(d.Deployer),
+		State:       str(state),
+		DryRun:      d.DryRun,
+		Created:     d.Created,
+	}
+	if d.GitHubID != nil {
+		deployment.GitHubID = *d.GitHubID
+	}
+	return deployment
+}
+
+// Cursors are opaque to clients; they encode the creation time and ID of the last deployment on a page.
+func encodeCursor(d Deployment) string {
+	raw := fmt.Sprintf("%s,%s", d.Created.Format(time.RFC3339Nano), d.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*database.DeploymentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(string(raw), ",", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	created, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, err
+	}
+	return &database.DeploymentCursor{
+		Created: created,
+		ID:      parts[1],
+	}, nil
+}
+
+func parseTime(query url.Values, key string) (time.Time, error) {
+	value := query.Get(key)
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' must be a RFC 3339 timestamp: %s", key, err)
+	}
+	return t, nil
+}
+
+// Teams the user is a member of, through the groups owning each team's API keys.
+func (h *DeploymentsHandler) userTeams(r *http.Request) ([]string, int, error) {
+	groups, err := api_v1.GroupClaims(r.Context())
+	if err != nil {
+		return nil, http.StatusUnauthorized, fmt.Errorf("%s: %s", api_v1.FailedAuthenticationMsg, err)
+	}
+
+	teams := make([]string, 0)
+
+	for _, group := range groups {
+		apiKeys, err := h.APIKeyStorage.ApiKeys(r.Context(), group)
+		if err != nil {
+			if database.IsErrNotFound(err) {
+				continue
+			}
+			return nil, http.StatusBadGateway, fmt.Errorf("unable to fetch team apikey from storage: %s", err)
+		}
+		for _, apiKey := range apiKeys {
+			if apiKey.GroupId == group && !contains(teams, apiKey.Team) {
+				teams = append(teams, apiKey.Team)
+			}
+		}
+	}
+
+	return teams, http.StatusOK, nil
+}
+
+// Build a database filter from the URL query string.
+func filter(query url.Values) (*database.DeploymentFilter, error) {
+	var err error
+
+	filter := &database.DeploymentFilter{
+		Team:       query.Get("team"),
+		Cluster:    query.Get("cluster"),
+		Repository: query.Get("repository"),
+		Ref:        query.Get("ref"),
+		State:      query.Get("state"),
+		Limit:      DefaultLimit,
+	}
+
+	filter.From, err = parseTime(query, "from")
+	if err != nil {
+		return nil, err
+	}
+
+	filter.To, err = parseTime(query, "to")
+	if err != nil {
+		return nil, err
+	}
+
+	if limit := query.Get("limit"); len(limit) > 0 {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > MaxLimit {
+			return nil, fmt.Errorf("'limit' must be a number between 1 and %d", MaxLimit)
+		}
+	}
+
+	if cursor := query.Get("cursor"); len(cursor) > 0 {
+		filter.Cursor, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %s", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// List deployments, newest first, optionally filtered by team, cluster, repository, ref, state and time range.
+// Only deployments belonging to the user's teams are listed.
+// If more deployments are available, the response contains a cursor that can be used to retrieve the next page.
+func (h *DeploymentsHandler) List(w http.ResponseWriter, r *http.Request) {
+	var response ListResponse
+
+	fields := middleware.RequestLogFields(r)
+	logger := log.WithFields(fields)
+
+	filter, err := filter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		response.Message = fmt.Sprintf("invalid query: %s", err)
+		render.JSON(w, r, response)
+		logger.Error(response.Message)
+		return
+	}
+
+	teams, code, err := h.userTeams(r)
+	if err != nil {
+		w.WriteHeader(code)
+		response.Message = api_v1.FailedAuthenticationMsg
+		render.JSON(w, r, response)
+		logger.Error(err)
+		return
+	}
+
+	if len(teams) == 0 {
+		w.WriteHeader(http.StatusOK)
+		render.JSON(w, r, response)
+		return
+	}
+
+	filter.Teams = teams
+
+	// Fetch one more than requested to find out if there is a next page.
+	limit := filter.Limit
+	filter.Limit++
+
+	deployments, err := h.DeploymentStore.Deployments(r.Context(), *filter)
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Message = "unable to list deployments; database is unavailable"
+		render.JSON(w, r, response)
+		logger.Errorf("%s: %s", response.Message, err)
+		return
+	}
+
+	response.Deployments = make([]Deployment, 0, len(deployments))
+	for i := range deployments {
+		if i == limit {
+			response.NextCursor = encodeCursor(response.Deployments[limit-1])
+			break
+		}
+		response.Deployments = append(response.Deployments, deployment(deployments[i].Deployment, deployments[i].State))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, response)
+}
+
+// Get a single deployment along with its complete status timeline, in chronological order.
+// Deployments belonging to other teams than the user's are reported as not found.
+func (h *DeploymentsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	var response DetailResponse
+
+	fields := middleware.RequestLogFields(r)
+	logger := log.WithFields(fields)
+
+	id := chi.URLParam(r, "id")
+
+	teams, code, err := h.userTeams(r)
+	if err != nil {
+		w.WriteHeader(code)
+		response.Message = api_v1.FailedAuthenticationMsg
+		render.JSON(w, r, response)
+		logger.Error(err)
+		return
+	}
+
+	dbDeployment, err := h.DeploymentStore.Deployment(r.Context(), id)
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+			response.Message = "deployment not found"
+			render.JSON(w, r, response)
+			logger.Errorf("deployment %s does not exist", id)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Message = "unable to retrieve deployment; database is unavailable"
+		render.JSON(w, r, response)
+		logger.Errorf("%s: %s", response.Message, err)
+		return
+	}
+
+	if !contains(teams, dbDeployment.Team) {
+		w.WriteHeader(http.StatusNotFound)
+		response.Message = "deployment not found"
+		render.JSON(w, r, response)
+		logger.Errorf("deployment %s belongs to team %s, which the user is not a member of", id, dbDeployment.Team)
+		return
+	}
+
+	statuses, err := h.DeploymentStore.DeploymentStatus(r.Context(), id)
+	if err != nil && !database.IsErrNotFound(err) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		response.Message = "unable to retrieve deployment status; database is unavailable"
+		render.JSON(w, r, response)
+		logger.Errorf("%s: %s", response.Message, err)
+		return
+	}
+
+	// Statuses are ordered by newest first.
+	var state *string
+	if len(statuses) > 0 {
+		state = &statuses[0].Status
+	}
+
+	d := deployment(*dbDeployment, state)
+	response.Deployment = &d
+	response.Statuses = make([]Status, len(statuses))
+	for i, status := range statuses {
+		response.Statuses[len(statuses)-i-1] = Status{
+			ID:        status.ID,
+			Status:    status.Status,
+			Message:   status.Message,
+			Diff:      str(status.Diff),
+			Resources: status.Resources,
+			Created:   status.Created,
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	render.JSON(w, r, response)
+}
@@ -0,0 +1,318 @@
+package api_v1_deployments_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/api"
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+var secretKey = []byte("foobar")
+
+// Requests with a body are HMAC signed, unless a signature header is given.
+// Requests without a body are authenticated with OAuth.
+type request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers"`
+	Body    json.RawMessage   `json:"body"`
+}
+
+type response struct {
+	StatusCode int             `json:"statusCode"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type testCase struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+type deploymentStorage struct{}
+
+type apiKeyStorage struct{}
+
+type deployServer struct {
+	pb.UnimplementedDeployServer
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func str(s string) *string {
+	return &s
+}
+
+var created = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// Ordered by newest first, as returned by the database.
+var deployments = []database.DeploymentSummary{
+	{
+		Deployment: database.Deployment{
+			ID:               "3",
+			Team:             "aura",
+			Created:          created.Add(2 * time.Hour),
+			GitHubRepository: str("navikt/deployment"),
+			Cluster:          str("dev-fss"),
+			Environment:      str("dev-fss:aura"),
+			Ref:              str("master"),
+			Deployer:         str("octocat"),
+		},
+		State: str("success"),
+	},
+	{
+		Deployment: database.Deployment{
+			ID:      "2",
+			Team:    "aura",
+			Created: created.Add(1 * time.Hour),
+			Cluster: str("prod-fss"),
+		},
+		State: str("failure"),
+	},
+	{
+		Deployment: database.Deployment{
+			ID:      "1",
+			Team:    "other",
+			Created: created,
+		},
+	},
+}
+
+func (s *deploymentStorage) Deployments(ctx context.Context, filter database.DeploymentFilter) ([]database.DeploymentSummary, error) {
+	if filter.Team == "unavailable" {
+		return nil, fmt.Errorf("oops")
+	}
+
+	result := make([]database.DeploymentSummary, 0)
+	for _, deployment := range deployments {
+		if len(result) == filter.Limit {
+			break
+		}
+		if len(filter.Teams) > 0 && !contains(filter.Teams, deployment.Team) {
+			continue
+		}
+		if len(filter.Team) > 0 && filter.Team != deployment.Team {
+			continue
+		}
+		if len(filter.State) > 0 && (deployment.State == nil || filter.State != *deployment.State) {
+			continue
+		}
+		if !filter.From.IsZero() && deployment.Created.Before(filter.From) {
+			continue
+		}
+		if filter.Cursor != nil && !deployment.Created.Before(filter.Cursor.Created) {
+			continue
+		}
+		result = append(result, deployment)
+	}
+	return result, nil
+}
+
+// Deployments that are still running, and thus not part of any listing.
+var running = []database.Deployment{
+	{
+		ID:      "4",
+		Team:    "aura",
+		Created: created.Add(3 * time.Hour),
+		Cluster: str("dev-fss"),
+	},
+	{
+		ID:      "5",
+		Team:    "aura",
+		Created: created.Add(3 * time.Hour),
+		Cluster: str("offline"),
+	},
+}
+
+func (s *deploymentStorage) Deployment(ctx context.Context, id string) (*database.Deployment, error) {
+	switch id {
+	case "unavailable":
+		return nil, fmt.Errorf("oops")
+	}
+	for _, deployment := range running {
+		if deployment.ID == id {
+			return &deployment, nil
+		}
+	}
+	for _, deployment := range deployments {
+		if deployment.ID == id {
+			return &deployment.Deployment, nil
+		}
+	}
+	return nil, database.ErrNotFound
+}
+
+func (s *deploymentStorage) DeploymentStatus(ctx context.Context, deploymentID string) ([]database.DeploymentStatus, error) {
+	switch deploymentID {
+	case "3":
+		return []database.DeploymentStatus{
+			{ID: "c", DeploymentID: "3", Status: "success", Message: "all resources deployed", Created: created.Add(2*time.Hour + 2*time.Minute), Resources: []api_v1.ResourceStatus{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "aura", Name: "deployment", State: "healthy", DurationMillis: 45000},
+			}},
+			{ID: "b", DeploymentID: "3", Status: "in_progress", Message: "deployment in progress", Created: created.Add(2*time.Hour + time.Minute)},
+			{ID: "a", DeploymentID: "3", Status: "queued", Message: "deployment request has been put on the queue for further processing", Created: created.Add(2 * time.Hour)},
+		}, nil
+	case "4", "5":
+		return []database.DeploymentStatus{
+			{ID: "d", DeploymentID: deploymentID, Status: "in_progress", Message: "deployment in progress", Created: created.Add(3 * time.Hour)},
+		}, nil
+	}
+	return nil, database.ErrNotFound
+}
+
+func (s *deploymentStorage) LatestDeployment(ctx context.Context, team, repository string) (*database.Deployment, error) {
+	return nil, database.ErrNotFound
+}
+
+func (s *deploymentStorage) WriteDeployment(ctx context.Context, deployment database.Deployment) error {
+	return nil
+}
+
+func (s *deploymentStorage) WriteDeploymentStatus(ctx context.Context, status database.DeploymentStatus) error {
+	return nil
+}
+
+// Every team has its API key owned by the group named after the team, and can be looked up by either.
+func (a *apiKeyStorage) ApiKeys(ctx context.Context, id string) (database.ApiKeys, error) {
+	team := strings.TrimSuffix(id, "-group")
+	if team == "nobody" {
+		return nil, database.ErrNotFound
+	}
+	return []database.ApiKey{{
+		Team:    team,
+		GroupId: team + "-group",
+		Key:     secretKey,
+		Expires: time.Now().Add(1 * time.Hour),
+	}}, nil
+}
+
+func (a *apiKeyStorage) RotateApiKey(ctx context.Context, team, groupId string, key []byte) error {
+	return nil
+}
+
+func (s *deployServer) SendDeploymentRequest(ctx context.Context, deployment pb.DeploymentRequest) error {
+	return nil
+}
+
+func (s *deployServer) HandleDeploymentStatus(ctx context.Context, status pb.DeploymentStatus) error {
+	return nil
+}
+
+func (s *deployServer) SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func()) {
+	return make(chan pb.DeploymentStatus), func() {}
+}
+
+func (s *deployServer) CancelDeployment(ctx context.Context, deploymentID, cluster string) error {
+	if cluster == "offline" {
+		return fmt.Errorf("cluster '%s' is offline", cluster)
+	}
+	return nil
+}
+
+// OAuth users are members of team aura, unless other groups are given in the X-Groups header.
+func tokenValidatorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		groups := []string{"aura-group"}
+		if header := r.Header.Get("X-Groups"); len(header) > 0 {
+			groups = strings.Split(header, ",")
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "groups", groups))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Add a current timestamp to the request body, and sign it.
+func sign(t *testing.T, request *http.Request, body json.RawMessage) {
+	payload := make(map[string]interface{})
+	err := json.Unmarshal(body, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload["timestamp"] = time.Now().Unix()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	request.Header.Set("content-type", "application/json")
+	if len(request.Header.Get(api_v1.SignatureHeader)) == 0 {
+		request.Header.Set(api_v1.SignatureHeader, hex.EncodeToString(api_v1.GenMAC(data, secretKey)))
+	}
+}
+
+func subTest(t *testing.T, name string) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("testdata/%s", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := testCase{}
+	err = json.Unmarshal(data, &test)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method := test.Request.Method
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, test.Request.Path, nil)
+
+	for key, val := range test.Request.Headers {
+		request.Header.Set(key, val)
+	}
+
+	if len(test.Request.Body) > 0 {
+		sign(t, request, test.Request.Body)
+	}
+
+	handler := api.New(api.Config{
+		ApiKeyStore:                 &apiKeyStorage{},
+		DeployServer:                &deployServer{},
+		DeploymentStore:             &deploymentStorage{},
+		MetricsPath:                 "/metrics",
+		OAuthKeyValidatorMiddleware: tokenValidatorMiddleware,
+	})
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, test.Response.StatusCode, recorder.Code)
+	assert.JSONEq(t, string(test.Response.Body), recorder.Body.String())
+}
+
+func TestHandler(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		name := file.Name()
+		t.Run(name, func(t *testing.T) {
+			subTest(t, name)
+		})
+	}
+}
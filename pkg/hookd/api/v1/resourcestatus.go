@@ -0,0 +1,13 @@
+package api_v1
+
+// ResourceStatus is the result of deploying a single Kubernetes resource.
+type ResourceStatus struct {
+	Group          string `json:"group,omitempty"`
+	Version        string `json:"version"`
+	Kind           string `json:"kind"`
+	Namespace      string `json:"namespace,omitempty"`
+	Name           string `json:"name"`
+	State          string `json:"state"`
+	Message        string `json:"message,omitempty"`
+	DurationMillis int64  `json:"durationMillis"`
+}
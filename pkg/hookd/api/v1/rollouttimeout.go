@@ -0,0 +1,28 @@
+package api_v1
+
+import (
+	"fmt"
+	"time"
+)
+
+// RolloutTimeout restricts how long deployd may be asked to monitor a rollout.
+// A zero Max means there is no upper bound.
+type RolloutTimeout struct {
+	Default time.Duration
+	Min     time.Duration
+	Max     time.Duration
+}
+
+// Effective returns the rollout timeout for a requested value, where zero selects the default.
+func (t RolloutTimeout) Effective(requested time.Duration) (time.Duration, error) {
+	if requested == 0 {
+		return t.Default, nil
+	}
+	if t.Max == 0 && requested < t.Min {
+		return 0, fmt.Errorf("rollout timeout must be at least %s", t.Min)
+	}
+	if requested < t.Min || (t.Max > 0 && requested > t.Max) {
+		return 0, fmt.Errorf("rollout timeout must be between %s and %s", t.Min, t.Max)
+	}
+	return requested, nil
+}
@@ -0,0 +1,38 @@
+package api_v1_test
+
+import (
+	"testing"
+	"time"
+
+	api_v1 "github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolloutTimeoutEffective(t *testing.T) {
+	bounded := api_v1.RolloutTimeout{Default: time.Minute * 30, Min: time.Minute, Max: time.Hour}
+	unbounded := api_v1.RolloutTimeout{Default: time.Minute * 30, Min: time.Minute}
+
+	tests := []struct {
+		bounds    api_v1.RolloutTimeout
+		requested time.Duration
+		effective time.Duration
+		err       string
+	}{
+		{bounds: bounded, requested: 0, effective: time.Minute * 30},
+		{bounds: bounded, requested: time.Minute * 5, effective: time.Minute * 5},
+		{bounds: bounded, requested: time.Second, err: "rollout timeout must be between 1m0s and 1h0m0s"},
+		{bounds: bounded, requested: time.Hour * 2, err: "rollout timeout must be between 1m0s and 1h0m0s"},
+		{bounds: unbounded, requested: time.Hour * 24, effective: time.Hour * 24},
+		{bounds: unbounded, requested: time.Second, err: "rollout timeout must be at least 1m0s"},
+	}
+
+	for _, test := range tests {
+		effective, err := test.bounds.Effective(test.requested)
+		if len(test.err) > 0 {
+			assert.EqualError(t, err, test.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, test.effective, effective)
+		}
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 
+	"github.com/navikt/deployment/pkg/grpc/deployserver"
 	"github.com/navikt/deployment/pkg/hookd/api/v1"
 	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/hookd/logproxy"
 	"github.com/navikt/deployment/pkg/hookd/middleware"
 
 	types "github.com/navikt/deployment/pkg/pb"
@@ -18,18 +21,36 @@ import (
 
 type StatusHandler struct {
 	APIKeyStorage   database.ApiKeyStore
+	BaseURL         string
+	DeployServer    deployserver.DeployServer
 	DeploymentStore database.DeploymentStore
 }
 
+// Either the deployment ID, or both the repository owner and name must be specified.
+// In the latter case, the most recent deployment from that repository is used.
 type StatusRequest struct {
-	DeploymentID string           `json:"deploymentID"`
+	DeploymentID string           `json:"deploymentID,omitempty"`
 	Team         string           `json:"team"`
+	Owner        string           `json:"owner,omitempty"`
+	Repository   string           `json:"repository,omitempty"`
 	Timestamp    api_v1.Timestamp `json:"timestamp"`
 }
 
 type StatusResponse struct {
-	Message string  `json:"message,omitempty"`
-	Status  *string `json:"status,omitempty"`
+	Message      string                  `json:"message,omitempty"`
+	Status       *string                 `json:"status,omitempty"`
+	DeploymentID string                  `json:"deploymentID,omitempty"`
+	LogURL       string                  `json:"logURL,omitempty"`
+	Diff         string                  `json:"diff,omitempty"`
+	Resources    []api_v1.ResourceStatus `json:"resources,omitempty"`
+	History      []StatusEntry           `json:"history,omitempty"`
+}
+
+// StatusEntry is a single state change in the timeline of a deployment.
+type StatusEntry struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message"`
+	Created time.Time `json:"created"`
 }
 
 func (r *StatusResponse) render(w io.Writer) {
@@ -37,8 +58,8 @@ func (r *StatusResponse) render(w io.Writer) {
 }
 
 func (r *StatusRequest) validate() error {
-	if len(r.DeploymentID) == 0 {
-		return fmt.Errorf("no deployment ID specified")
+	if len(r.DeploymentID) == 0 && (len(r.Owner) == 0 || len(r.Repository) == 0) {
+		return fmt.Errorf("no deployment ID or repository specified")
 	}
 
 	if len(r.Team) == 0 {
@@ -52,22 +73,40 @@ func (r *StatusRequest) validate() error {
 	return nil
 }
 
+func (r *StatusRequest) GithubRepository() *types.GithubRepository {
+	return &types.GithubRepository{
+		Owner: r.Owner,
+		Name:  r.Repository,
+	}
+}
+
 func (r *StatusRequest) LogFields() log.Fields {
 	return log.Fields{
 		types.LogFieldDeploymentID: r.DeploymentID,
 		types.LogFieldTeam:         r.Team,
+		types.LogFieldRepository:   r.GithubRepository().FullName(),
 	}
 }
 
-func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+// Convert database statuses, ordered by newest first, to a chronological timeline.
+func timeline(statuses []database.DeploymentStatus) []StatusEntry {
+	entries := make([]StatusEntry, len(statuses))
+	for i, status := range statuses {
+		entries[len(statuses)-i-1] = StatusEntry{
+			Status:  status.Status,
+			Message: status.Message,
+			Created: status.Created,
+		}
+	}
+	return entries
+}
+
+// Parse, validate and authenticate an incoming status request, and resolve the deployment ID
+// if the request refers to a repository. Returns nil if an error response has been written.
+func (h *StatusHandler) authenticate(w http.ResponseWriter, r *http.Request, logger *log.Entry) (*StatusRequest, *log.Entry) {
 	var err error
 	var statusResponse StatusResponse
 
-	fields := middleware.RequestLogFields(r)
-	logger := log.WithFields(fields)
-
-	logger.Tracef("Incoming status request")
-
 	data, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -75,7 +114,7 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusResponse.render(w)
 
 		logger.Error(statusResponse.Message)
-		return
+		return nil, nil
 	}
 
 	encodedSignature := r.Header.Get(api_v1.SignatureHeader)
@@ -85,7 +124,7 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusResponse.Message = "HMAC digest must be hex encoded"
 		statusResponse.render(w)
 		logger.Errorf("unable to validate team: %s: %s", statusResponse.Message, err)
-		return
+		return nil, nil
 	}
 
 	logger.Tracef("Request has hex encoded data in signature header")
@@ -96,7 +135,7 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusResponse.Message = fmt.Sprintf("unable to unmarshal request body: %s", err)
 		statusResponse.render(w)
 		logger.Error(statusResponse.Message)
-		return
+		return nil, nil
 	}
 
 	logger = logger.WithFields(statusRequest.LogFields())
@@ -109,7 +148,7 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusResponse.Message = fmt.Sprintf("invalid status request: %s", err)
 		statusResponse.render(w)
 		logger.Error(statusResponse.Message)
-		return
+		return nil, nil
 	}
 
 	logger.Tracef("Request body validated successfully")
@@ -121,14 +160,14 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			statusResponse.Message = api_v1.FailedAuthenticationMsg
 			statusResponse.render(w)
 			logger.Errorf("%s: %s", api_v1.FailedAuthenticationMsg, err)
-			return
+			return nil, nil
 		}
 
 		w.WriteHeader(http.StatusBadGateway)
 		statusResponse.Message = "something wrong happened when communicating with api key service"
 		statusResponse.render(w)
 		logger.Errorf("unable to fetch team apikey from storage: %s", err)
-		return
+		return nil, nil
 	}
 
 	logger.Tracef("Team API keys retrieved from storage")
@@ -139,21 +178,63 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusResponse.Message = api_v1.FailedAuthenticationMsg
 		statusResponse.render(w)
 		logger.Error(err)
-		return
+		return nil, nil
 	}
 
 	logger.Tracef("HMAC signature validated successfully")
 
+	if len(statusRequest.DeploymentID) == 0 {
+		logger.Tracef("Querying database for latest deployment")
+
+		deployment, err := h.DeploymentStore.LatestDeployment(r.Context(), statusRequest.Team, statusRequest.GithubRepository().FullName())
+
+		if err != nil {
+			if database.IsErrNotFound(err) {
+				w.WriteHeader(http.StatusNotFound)
+				statusResponse.Message = "deployment not found"
+				statusResponse.render(w)
+				logger.Errorf("no deployments found for repository %s", statusRequest.GithubRepository().FullName())
+				return nil, nil
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			statusResponse.Message = "unable to determine deployment status; database is unavailable"
+			statusResponse.render(w)
+			logger.Errorf("%s: %s", statusResponse.Message, err)
+			return nil, nil
+		}
+
+		statusRequest.DeploymentID = deployment.ID
+		logger = logger.WithField(types.LogFieldDeploymentID, deployment.ID)
+	}
+
+	return statusRequest, logger
+}
+
+func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var statusResponse StatusResponse
+
+	fields := middleware.RequestLogFields(r)
+	logger := log.WithFields(fields)
+
+	logger.Tracef("Incoming status request")
+
+	statusRequest, logger := h.authenticate(w, r, logger)
+	if statusRequest == nil {
+		return
+	}
+
+	deploymentID := statusRequest.DeploymentID
+
 	logger.Tracef("Querying database for deployment status")
 
-	deploymentStatus, err := h.DeploymentStore.DeploymentStatus(r.Context(), statusRequest.DeploymentID)
+	deploymentStatus, err := h.DeploymentStore.DeploymentStatus(r.Context(), deploymentID)
 
 	if err != nil {
 		if database.IsErrNotFound(err) {
 			w.WriteHeader(http.StatusNotFound)
 			statusResponse.Message = "deployment not found"
 			statusResponse.render(w)
-			logger.Errorf("deployment %s does not exist", statusRequest.DeploymentID)
+			logger.Errorf("deployment %s does not exist", deploymentID)
 			return
 		}
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -163,10 +244,19 @@ func (h *StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	history := timeline(deploymentStatus)
+
 	w.WriteHeader(http.StatusOK)
 	state := deploymentStatus[0]
 	statusResponse.Status = &state.Status
 	statusResponse.Message = state.Message
+	statusResponse.DeploymentID = deploymentID
+	statusResponse.LogURL = logproxy.MakeURL(h.BaseURL, deploymentID, history[0].Created)
+	statusResponse.History = history
+	if state.Diff != nil {
+		statusResponse.Diff = *state.Diff
+	}
+	statusResponse.Resources = state.Resources
 	statusResponse.render(w)
 
 	logger.Tracef("Status request processed successfully")
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
@@ -17,11 +18,14 @@ import (
 	"github.com/navikt/deployment/pkg/hookd/api/v1"
 	"github.com/navikt/deployment/pkg/hookd/api/v1/status"
 	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
 	"github.com/stretchr/testify/assert"
 )
 
 var secretKey = []byte("foobar")
 
+var streamingCreated = time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC)
+
 const (
 	deploymentID = 123789
 )
@@ -68,6 +72,24 @@ func (s *deploymentStorage) Deployment(ctx context.Context, id string) (*databas
 	return nil, nil
 }
 
+func (s *deploymentStorage) LatestDeployment(ctx context.Context, team, repository string) (*database.Deployment, error) {
+	switch repository {
+	case "foo/unavailable":
+		return nil, fmt.Errorf("unavailable")
+	case "foo/notfound":
+		return nil, database.ErrNotFound
+	default:
+		return &database.Deployment{
+			ID:   "123",
+			Team: team,
+		}, nil
+	}
+}
+
+func (s *deploymentStorage) Deployments(ctx context.Context, filter database.DeploymentFilter) ([]database.DeploymentSummary, error) {
+	return nil, nil
+}
+
 func (s *deploymentStorage) WriteDeployment(ctx context.Context, deployment database.Deployment) error {
 	return nil
 }
@@ -78,6 +100,56 @@ func (s *deploymentStorage) DeploymentStatus(ctx context.Context, deploymentID s
 		return nil, fmt.Errorf("unavailable")
 	case "notfound":
 		return nil, database.ErrNotFound
+	case "streaming":
+		return []database.DeploymentStatus{
+			{
+				ID:           "foo",
+				DeploymentID: "streaming",
+				Status:       "in_progress",
+				Message:      "waiting for rollout",
+				Created:      streamingCreated,
+			},
+		}, nil
+	case "dryrun":
+		diff := "--- live\n+++ dry-run\n@@ -1 +1 @@\n-replicas: 1\n+replicas: 2\n"
+		return []database.DeploymentStatus{
+			{
+				ID:           "foo",
+				DeploymentID: "dryrun",
+				Status:       "success",
+				Message:      "dry run completed",
+				Diff:         &diff,
+			},
+		}, nil
+	case "failedresources":
+		return []database.DeploymentStatus{
+			{
+				ID:           "foo",
+				DeploymentID: "failedresources",
+				Status:       "failure",
+				Message:      "1 of 2 resources failed",
+				Resources: []api_v1.ResourceStatus{
+					{
+						Group:          "apps",
+						Version:        "v1",
+						Kind:           "Deployment",
+						Namespace:      "nobody",
+						Name:           "myapplication",
+						State:          "failed",
+						Message:        "pod myapplication-abc123: CrashLoopBackOff",
+						DurationMillis: 90000,
+					},
+					{
+						Version:        "v1",
+						Kind:           "ConfigMap",
+						Namespace:      "nobody",
+						Name:           "myconfig",
+						State:          "applied",
+						DurationMillis: 12,
+					},
+				},
+			},
+		}, nil
 	default:
 		return []database.DeploymentStatus{
 			{
@@ -101,6 +173,8 @@ func testStatusResponse(t *testing.T, recorder *httptest.ResponseRecorder, respo
 	assert.Equal(t, response.StatusCode, recorder.Code)
 	assert.Equal(t, response.Body.Message, decodedBody.Message)
 	assert.Equal(t, response.Body.Status, decodedBody.Status)
+	assert.Equal(t, response.Body.Diff, decodedBody.Diff)
+	assert.Equal(t, response.Body.Resources, decodedBody.Resources)
 }
 
 // Inject timestamp in request payload
@@ -192,3 +266,65 @@ func TestHandler(t *testing.T) {
 		})
 	}
 }
+
+type deployServer struct {
+	pb.UnimplementedDeployServer
+	statuses []pb.DeploymentStatus
+}
+
+func (s *deployServer) SendDeploymentRequest(ctx context.Context, deployment pb.DeploymentRequest) error {
+	return nil
+}
+
+func (s *deployServer) HandleDeploymentStatus(ctx context.Context, status pb.DeploymentStatus) error {
+	return nil
+}
+
+func (s *deployServer) SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func()) {
+	ch := make(chan pb.DeploymentStatus, len(s.statuses))
+	for _, status := range s.statuses {
+		ch <- status
+	}
+	return ch, func() {}
+}
+
+func (s *deployServer) CancelDeployment(ctx context.Context, deploymentID, cluster string) error {
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	body := addTimestampToBody([]byte(`{"deploymentID":"streaming","team":"nobody"}`), 0)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("POST", "/api/v1/status/stream", bytes.NewReader(body))
+	request.Header.Set("content-type", "application/json")
+	request.Header.Set(api_v1.SignatureHeader, hex.EncodeToString(api_v1.GenMAC(body, secretKey)))
+
+	handler := api.New(api.Config{
+		ApiKeyStore:     &apiKeyStorage{},
+		DeploymentStore: &deploymentStorage{},
+		DeployServer: &deployServer{
+			statuses: []pb.DeploymentStatus{
+				{DeliveryID: "streaming", State: pb.GithubDeploymentState_in_progress, Description: "waiting for rollout", Time: pb.TimeAsTimestamp(streamingCreated)},
+				{DeliveryID: "streaming", State: pb.GithubDeploymentState_success, Description: "all resources deployed", Time: pb.TimeAsTimestamp(streamingCreated.Add(time.Second))},
+			},
+		},
+		MetricsPath: "/metrics",
+	})
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, api_v1_status.StreamContentType, recorder.Header().Get("content-type"))
+
+	states := make([]string, 0)
+	decoder := json.NewDecoder(recorder.Body)
+	for decoder.More() {
+		response := api_v1_status.StatusResponse{}
+		err := decoder.Decode(&response)
+		assert.NoError(t, err)
+		assert.Equal(t, "streaming", response.DeploymentID)
+		states = append(states, *response.Status)
+	}
+
+	assert.Equal(t, []string{"in_progress", "success"}, states)
+}
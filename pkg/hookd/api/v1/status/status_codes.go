@@ -9,6 +9,8 @@ var StatusCodes = []int{
 	http.StatusNoContent,
 	http.StatusBadRequest,
 	http.StatusForbidden,
+	http.StatusNotFound,
+	http.StatusServiceUnavailable,
 	http.StatusBadGateway,
 	http.StatusInternalServerError,
 }
@@ -0,0 +1,144 @@
+package api_v1_status
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/hookd/middleware"
+	types "github.com/navikt/deployment/pkg/pb"
+	log "github.com/sirupsen/logrus"
+)
+
+const StreamContentType = "application/x-ndjson"
+
+var (
+	// How often the database is checked for new statuses while streaming.
+	// This picks up statuses written through other hookd instances, and keeps idle connections alive.
+	streamRefreshInterval = time.Second * 15
+)
+
+// Returns true if the deployment state will never change again.
+func terminalState(state string) bool {
+	value, ok := types.GithubDeploymentState_value[state]
+	return ok && types.GithubDeploymentState(value).Finished()
+}
+
+// Stream writes every status of a deployment as a separate line of JSON, until the deployment
+// reaches a terminal state or the client disconnects. The request format is identical to the status request.
+func (h *StatusHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	var statusResponse StatusResponse
+
+	fields := middleware.RequestLogFields(r)
+	logger := log.WithFields(fields)
+
+	logger.Tracef("Incoming status stream request")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		statusResponse.Message = "status streaming is not supported by this server"
+		statusResponse.render(w)
+		logger.Error(statusResponse.Message)
+		return
+	}
+
+	statusRequest, logger := h.authenticate(w, r, logger)
+	if statusRequest == nil {
+		return
+	}
+
+	deploymentID := statusRequest.DeploymentID
+
+	// Subscribe before reading the current status, so that no updates are lost in between.
+	updates, unsubscribe := h.DeployServer.SubscribeDeploymentStatus(deploymentID)
+	defer unsubscribe()
+
+	deploymentStatus, err := h.DeploymentStore.DeploymentStatus(r.Context(), deploymentID)
+
+	if err != nil {
+		if database.IsErrNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+			statusResponse.Message = "deployment not found"
+			statusResponse.render(w)
+			logger.Errorf("deployment %s does not exist", deploymentID)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		statusResponse.Message = "unable to determine deployment status; database is unavailable"
+		statusResponse.render(w)
+		logger.Errorf("%s: %s", statusResponse.Message, err)
+		return
+	}
+
+	w.Header().Set("content-type", StreamContentType)
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	var last StatusEntry
+
+	// Write a status to the client, and return true if the stream should be closed.
+	// Statuses arrive both through the subscription and the database refresh, so anything
+	// not newer than the last status sent is either a duplicate or out of order, and is skipped.
+	send := func(status StatusEntry) bool {
+		if !status.Created.After(last.Created) {
+			return false
+		}
+		if status.Status == last.Status && status.Message == last.Message {
+			return false
+		}
+		last = status
+		err := encoder.Encode(&StatusResponse{
+			Status:       &status.Status,
+			Message:      status.Message,
+			DeploymentID: deploymentID,
+		})
+		if err != nil {
+			logger.Errorf("unable to write status to stream: %s", err)
+			return true
+		}
+		flusher.Flush()
+		return terminalState(status.Status)
+	}
+
+	if send(timeline(deploymentStatus[:1])[0]) {
+		return
+	}
+
+	logger.Tracef("Streaming deployment status")
+
+	ticker := time.NewTicker(streamRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Tracef("Client closed status stream")
+			return
+
+		case status := <-updates:
+			entry := StatusEntry{
+				Status:  status.GetState().String(),
+				Message: status.GetDescription(),
+				Created: status.Timestamp(),
+			}
+			if send(entry) {
+				return
+			}
+
+		case <-ticker.C:
+			deploymentStatus, err := h.DeploymentStore.DeploymentStatus(r.Context(), deploymentID)
+			if err != nil {
+				logger.Warnf("unable to refresh deployment status: %s", err)
+				continue
+			}
+			if send(timeline(deploymentStatus[:1])[0]) {
+				return
+			}
+			// keep the connection alive; whitespace is ignored by JSON decoders
+			w.Write([]byte("\n"))
+			flusher.Flush()
+		}
+	}
+}
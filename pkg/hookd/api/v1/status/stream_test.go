@@ -0,0 +1,124 @@
+package api_v1_status
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/navikt/deployment/pkg/grpc/deployserver"
+	"github.com/navikt/deployment/pkg/hookd/api/v1"
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+var streamKey = []byte("foobar")
+
+type streamKeyStore struct {
+	database.ApiKeyStore
+}
+
+func (s *streamKeyStore) ApiKeys(ctx context.Context, team string) (database.ApiKeys, error) {
+	return []database.ApiKey{{
+		Key:     streamKey,
+		Expires: time.Now().Add(time.Hour),
+	}}, nil
+}
+
+// streamStore returns one database state per call, repeating the last one when exhausted.
+type streamStore struct {
+	database.DeploymentStore
+	lock     sync.Mutex
+	statuses [][]database.DeploymentStatus
+}
+
+func (s *streamStore) DeploymentStatus(ctx context.Context, deploymentID string) ([]database.DeploymentStatus, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	statuses := s.statuses[0]
+	if len(s.statuses) > 1 {
+		s.statuses = s.statuses[1:]
+	}
+	return statuses, nil
+}
+
+type streamServer struct {
+	deployserver.DeployServer
+	updates []pb.DeploymentStatus
+}
+
+func (s *streamServer) SubscribeDeploymentStatus(deploymentID string) (<-chan pb.DeploymentStatus, func()) {
+	ch := make(chan pb.DeploymentStatus, len(s.updates))
+	for _, status := range s.updates {
+		ch <- status
+	}
+	return ch, func() {}
+}
+
+func TestStreamSkipsOutdatedStatuses(t *testing.T) {
+	defer func(interval time.Duration) {
+		streamRefreshInterval = interval
+	}(streamRefreshInterval)
+	streamRefreshInterval = time.Millisecond * 10
+
+	created := time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC)
+	status := func(state, message string, offset time.Duration) database.DeploymentStatus {
+		return database.DeploymentStatus{
+			DeploymentID: "123",
+			Status:       state,
+			Message:      message,
+			Created:      created.Add(offset),
+		}
+	}
+
+	handler := &StatusHandler{
+		APIKeyStorage: &streamKeyStore{},
+		DeploymentStore: &streamStore{
+			statuses: [][]database.DeploymentStatus{
+				{status("queued", "deployment request queued", 0)},
+				// written through another hookd instance, before the update already received through the subscription
+				{status("in_progress", "applying resources", time.Second*2)},
+				{status("success", "all resources deployed", time.Second*4)},
+			},
+		},
+		DeployServer: &streamServer{
+			updates: []pb.DeploymentStatus{
+				{
+					DeliveryID:  "123",
+					State:       pb.GithubDeploymentState_in_progress,
+					Description: "waiting for rollout",
+					Time:        pb.TimeAsTimestamp(created.Add(time.Second * 3)),
+				},
+			},
+		},
+	}
+
+	body := []byte(fmt.Sprintf(`{"deploymentID":"123","team":"nobody","timestamp":%d}`, time.Now().Unix()))
+	request := httptest.NewRequest("POST", "/api/v1/status/stream", bytes.NewReader(body))
+	request.Header.Set(api_v1.SignatureHeader, hex.EncodeToString(api_v1.GenMAC(body, streamKey)))
+	recorder := httptest.NewRecorder()
+
+	handler.Stream(recorder, request)
+
+	messages := make([]string, 0)
+	decoder := json.NewDecoder(recorder.Body)
+	for decoder.More() {
+		response := StatusResponse{}
+		err := decoder.Decode(&response)
+		assert.NoError(t, err)
+		messages = append(messages, *response.Status+": "+response.Message)
+	}
+
+	assert.Equal(t, []string{
+		"queued: deployment request queued",
+		"in_progress: waiting for rollout",
+		"success: all resources deployed",
+	}, messages)
+}
@@ -13,7 +13,8 @@ import (
 )
 
 var (
-	ErrNotFound = fmt.Errorf("database row not found")
+	ErrNotFound  = fmt.Errorf("database row not found")
+	ErrDuplicate = fmt.Errorf("database row already exists")
 )
 
 type database struct {
@@ -25,6 +26,10 @@ func IsErrNotFound(err error) bool {
 	return err == ErrNotFound
 }
 
+func IsErrDuplicate(err error) bool {
+	return err == ErrDuplicate
+}
+
 // Returns true if the error message is a foreign key constraint violation
 func IsErrForeignKeyViolation(err error) bool {
 	return strings.Contains(err.Error(), "SQLSTATE 23503")
@@ -2,7 +2,13 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v4"
+	api_v1 "github.com/navikt/deployment/pkg/hookd/api/v1"
 )
 
 type Deployment struct {
@@ -11,6 +17,42 @@ type Deployment struct {
 	Created          time.Time
 	GitHubID         *int
 	GitHubRepository *string
+	Cluster          *string
+	Environment      *string
+	Ref              *string
+	Deployer         *string
+	DryRun           bool
+}
+
+// DeploymentSummary is a deployment along with its current state, i.e. the most recent status recorded.
+// State is nil if no status has been recorded yet.
+type DeploymentSummary struct {
+	Deployment
+	State *string
+}
+
+// DeploymentCursor identifies a position in a list of deployments ordered by creation time, newest first.
+type DeploymentCursor struct {
+	Created time.Time
+	ID      string
+}
+
+// DeploymentFilter restricts which deployments are returned by Deployments. Empty fields are ignored.
+type DeploymentFilter struct {
+	// Only return deployments belonging to one of these teams.
+	Teams []string
+
+	Team       string
+	Cluster    string
+	Repository string
+	Ref        string
+	State      string
+	From       time.Time
+	To         time.Time
+
+	// Only return deployments older than the cursor.
+	Cursor *DeploymentCursor
+	Limit  int
 }
 
 type DeploymentStatus struct {
@@ -19,10 +61,14 @@ type DeploymentStatus struct {
 	Status       string
 	Message      string
 	Created      time.Time
+	Diff         *string
+	Resources    []api_v1.ResourceStatus
 }
 
 type DeploymentStore interface {
 	Deployment(ctx context.Context, id string) (*Deployment, error)
+	LatestDeployment(ctx context.Context, team, repository string) (*Deployment, error)
+	Deployments(ctx context.Context, filter DeploymentFilter) ([]DeploymentSummary, error)
 	WriteDeployment(ctx context.Context, deployment Deployment) error
 	DeploymentStatus(ctx context.Context, deploymentID string) ([]DeploymentStatus, error)
 	WriteDeploymentStatus(ctx context.Context, status DeploymentStatus) error
@@ -30,24 +76,150 @@ type DeploymentStore interface {
 
 var _ DeploymentStore = &database{}
 
+const selectDeploymentFields = `id, team, created, github_id, github_repository, cluster, environment, ref, deployer, dry_run`
+
 func (db *database) Deployment(ctx context.Context, id string) (*Deployment, error) {
-	query := `SELECT id, team, created, github_id, github_repository FROM deployment WHERE id = $1;`
+	query := `SELECT ` + selectDeploymentFields + ` FROM deployment WHERE id = $1;`
 	rows, err := db.timedQuery(ctx, query, id)
 
 	if err != nil {
 		return nil, err
 	}
 
+	return scanDeployment(rows)
+}
+
+// Retrieve the most recent deployment made by a team from a specific repository.
+func (db *database) LatestDeployment(ctx context.Context, team, repository string) (*Deployment, error) {
+	query := `
+SELECT ` + selectDeploymentFields + ` FROM deployment
+WHERE team = $1 AND github_repository = $2
+ORDER BY created DESC
+LIMIT 1;
+`
+	rows, err := db.timedQuery(ctx, query, team, repository)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanDeployment(rows)
+}
+
+// Retrieve deployments matching a filter, along with their current state, ordered by newest first.
+func (db *database) Deployments(ctx context.Context, filter DeploymentFilter) ([]DeploymentSummary, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	where := func(condition string, values ...interface{}) {
+		placeholders := make([]interface{}, len(values))
+		for i := range values {
+			args = append(args, values[i])
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if len(filter.Teams) > 0 {
+		where("d.team = ANY(%s)", filter.Teams)
+	}
+	if len(filter.Team) > 0 {
+		where("d.team = %s", filter.Team)
+	}
+	if len(filter.Cluster) > 0 {
+		where("d.cluster = %s", filter.Cluster)
+	}
+	if len(filter.Repository) > 0 {
+		where("d.github_repository = %s", filter.Repository)
+	}
+	if len(filter.Ref) > 0 {
+		where("d.ref = %s", filter.Ref)
+	}
+	if len(filter.State) > 0 {
+		where("s.status = %s", filter.State)
+	}
+	if !filter.From.IsZero() {
+		where("d.created >= %s", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("d.created < %s", filter.To)
+	}
+	if filter.Cursor != nil {
+		where("(d.created, d.id) < (%s, %s)", filter.Cursor.Created, filter.Cursor.ID)
+	}
+
+	query := `
+SELECT d.id, d.team, d.created, d.github_id, d.github_repository, d.cluster, d.environment, d.ref, d.deployer, d.dry_run, s.status
+FROM deployment d
+LEFT JOIN LATERAL (
+    SELECT status FROM deployment_status
+    WHERE deployment_id = d.id
+    ORDER BY created DESC
+    LIMIT 1
+) s ON true
+`
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + "\n"
+	}
+	query += "ORDER BY d.created DESC, d.id DESC\n"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf("LIMIT $%d\n", len(args))
+	}
+
+	rows, err := db.timedQuery(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := make([]DeploymentSummary, 0)
+
+	defer rows.Close()
+	for rows.Next() {
+		deployment := DeploymentSummary{}
+
+		err := rows.Scan(
+			&deployment.ID,
+			&deployment.Team,
+			&deployment.Created,
+			&deployment.GitHubID,
+			&deployment.GitHubRepository,
+			&deployment.Cluster,
+			&deployment.Environment,
+			&deployment.Ref,
+			&deployment.Deployer,
+			&deployment.DryRun,
+			&deployment.State,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	return deployments, rows.Err()
+}
+
+func scanDeployment(rows pgx.Rows) (*Deployment, error) {
 	defer rows.Close()
 	for rows.Next() {
 		deployment := &Deployment{}
 
+		// see selectDeploymentFields
 		err := rows.Scan(
 			&deployment.ID,
 			&deployment.Team,
 			&deployment.Created,
 			&deployment.GitHubID,
 			&deployment.GitHubRepository,
+			&deployment.Cluster,
+			&deployment.Environment,
+			&deployment.Ref,
+			&deployment.Deployer,
+			&deployment.DryRun,
 		)
 
 		if err != nil {
@@ -64,8 +236,8 @@ func (db *database) WriteDeployment(ctx context.Context, deployment Deployment)
 	var query string
 
 	query = `
-INSERT INTO deployment (id, team, created, github_id, github_repository)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO deployment (id, team, created, github_id, github_repository, cluster, environment, ref, deployer, dry_run)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 ON CONFLICT (id) DO UPDATE
 SET github_id = EXCLUDED.github_id, github_repository = EXCLUDED.github_repository;
 `
@@ -75,13 +247,18 @@ SET github_id = EXCLUDED.github_id, github_repository = EXCLUDED.github_reposito
 		deployment.Created,
 		deployment.GitHubID,
 		deployment.GitHubRepository,
+		deployment.Cluster,
+		deployment.Environment,
+		deployment.Ref,
+		deployment.Deployer,
+		deployment.DryRun,
 	)
 
 	return err
 }
 
 func (db *database) DeploymentStatus(ctx context.Context, deploymentID string) ([]DeploymentStatus, error) {
-	query := `SELECT id, deployment_id, status, message, created FROM deployment_status WHERE deployment_id = $1 ORDER BY created DESC;`
+	query := `SELECT id, deployment_id, status, message, created, diff, resources FROM deployment_status WHERE deployment_id = $1 ORDER BY created DESC;`
 	rows, err := db.timedQuery(ctx, query, deploymentID)
 
 	if err != nil {
@@ -93,6 +270,7 @@ func (db *database) DeploymentStatus(ctx context.Context, deploymentID string) (
 	defer rows.Close()
 	for rows.Next() {
 		status := DeploymentStatus{}
+		var resources []byte
 
 		// see selectApiKeyFields
 		err := rows.Scan(
@@ -101,12 +279,21 @@ func (db *database) DeploymentStatus(ctx context.Context, deploymentID string) (
 			&status.Status,
 			&status.Message,
 			&status.Created,
+			&status.Diff,
+			&resources,
 		)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if len(resources) > 0 {
+			err = json.Unmarshal(resources, &status.Resources)
+			if err != nil {
+				return nil, fmt.Errorf("decode resource statuses: %w", err)
+			}
+		}
+
 		statuses = append(statuses, status)
 	}
 
@@ -117,20 +304,41 @@ func (db *database) DeploymentStatus(ctx context.Context, deploymentID string) (
 	return statuses, nil
 }
 
+// WriteDeploymentStatus stores a deployment status. Statuses are identified by their ID, and
+// ErrDuplicate is returned if a status with the same ID has already been stored.
 func (db *database) WriteDeploymentStatus(ctx context.Context, status DeploymentStatus) error {
 	var query string
+	var resources []byte
+	var err error
+
+	if len(status.Resources) > 0 {
+		resources, err = json.Marshal(status.Resources)
+		if err != nil {
+			return fmt.Errorf("encode resource statuses: %w", err)
+		}
+	}
 
 	query = `
-INSERT INTO deployment_status (id, deployment_id, status, message, created)
-VALUES ($1, $2, $3, $4, $5);
+INSERT INTO deployment_status (id, deployment_id, status, message, created, diff, resources)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (id) DO NOTHING;
 `
-	_, err := db.conn.Exec(ctx, query,
+	tag, err := db.conn.Exec(ctx, query,
 		status.ID,
 		status.DeploymentID,
 		status.Status,
 		status.Message,
 		status.Created,
+		status.Diff,
+		resources,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if tag.RowsAffected() == 0 {
+		return ErrDuplicate
+	}
+
+	return nil
 }
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// DeploymentRequest is a deployment request that has not yet finished. Requests are kept until deployd
+// acknowledges them and their deployment reaches a final state, so that deployd can resume monitoring them after a restart.
+// The payload is opaque to the database, see the database_mapper package.
+//
+// Delivered is set when the request was last sent to a cluster, and Acknowledged when deployd
+// confirmed that the request was received. Instance identifies the receiving deployd instance.
+type DeploymentRequest struct {
+	ID           string
+	Cluster      string
+	Payload      []byte
+	Created      time.Time
+	Deadline     time.Time
+	Delivered    *time.Time
+	Acknowledged *time.Time
+	Instance     *string
+}
+
+type DeploymentRequestStore interface {
+	QueueDeploymentRequest(ctx context.Context, request DeploymentRequest) error
+	PendingDeploymentRequests(ctx context.Context, cluster string) ([]DeploymentRequest, error)
+	UnacknowledgedDeploymentRequests(ctx context.Context, deliveredBefore time.Time) ([]DeploymentRequest, error)
+	ExpiredDeploymentRequests(ctx context.Context, deadline time.Time) ([]DeploymentRequest, error)
+	MarkDeploymentRequestDelivered(ctx context.Context, id, instance string) error
+	AcknowledgeDeploymentRequest(ctx context.Context, id, instance string) error
+	DeleteDeploymentRequest(ctx context.Context, id string) error
+	DeleteAcknowledgedDeploymentRequests(ctx context.Context, createdBefore time.Time) (int64, error)
+	UnfinishedDeploymentRequests(ctx context.Context, cluster string, createdAfter time.Time) ([]DeploymentRequest, error)
+}
+
+var _ DeploymentRequestStore = &database{}
+
+const selectDeploymentRequestFields = `id, cluster, payload, created, deadline, delivered, acknowledged, instance`
+
+func (db *database) QueueDeploymentRequest(ctx context.Context, request DeploymentRequest) error {
+	var query string
+
+	query = `
+INSERT INTO deployment_request (id, cluster, payload, created, deadline)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (id) DO NOTHING;
+`
+	_, err := db.conn.Exec(ctx, query,
+		request.ID,
+		request.Cluster,
+		request.Payload,
+		request.Created,
+		request.Deadline,
+	)
+
+	return err
+}
+
+// Retrieve all unacknowledged deployment requests for a cluster, in the order they were created.
+func (db *database) PendingDeploymentRequests(ctx context.Context, cluster string) ([]DeploymentRequest, error) {
+	query := `
+SELECT ` + selectDeploymentRequestFields + ` FROM deployment_request
+WHERE cluster = $1 AND acknowledged IS NULL
+ORDER BY created ASC;
+`
+	return db.deploymentRequests(ctx, query, cluster)
+}
+
+// Retrieve all deployment requests, regardless of cluster, that were delivered before
+// the given time and have not yet been acknowledged.
+func (db *database) UnacknowledgedDeploymentRequests(ctx context.Context, deliveredBefore time.Time) ([]DeploymentRequest, error) {
+	query := `
+SELECT ` + selectDeploymentRequestFields + ` FROM deployment_request
+WHERE delivered < $1 AND acknowledged IS NULL
+ORDER BY created ASC;
+`
+	return db.deploymentRequests(ctx, query, deliveredBefore)
+}
+
+// Retrieve all unacknowledged deployment requests, regardless of cluster, whose deadline is before the given time.
+func (db *database) ExpiredDeploymentRequests(ctx context.Context, deadline time.Time) ([]DeploymentRequest, error) {
+	query := `
+SELECT ` + selectDeploymentRequestFields + ` FROM deployment_request
+WHERE deadline < $1 AND acknowledged IS NULL
+ORDER BY created ASC;
+`
+	return db.deploymentRequests(ctx, query, deadline)
+}
+
+func (db *database) MarkDeploymentRequestDelivered(ctx context.Context, id, instance string) error {
+	query := `UPDATE deployment_request SET delivered = now(), instance = $2 WHERE id = $1;`
+	_, err := db.conn.Exec(ctx, query, id, instance)
+
+	return err
+}
+
+func (db *database) AcknowledgeDeploymentRequest(ctx context.Context, id, instance string) error {
+	query := `UPDATE deployment_request SET acknowledged = now(), instance = $2 WHERE id = $1;`
+	tag, err := db.conn.Exec(ctx, query, id, instance)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (db *database) DeleteDeploymentRequest(ctx context.Context, id string) error {
+	query := `DELETE FROM deployment_request WHERE id = $1;`
+	_, err := db.conn.Exec(ctx, query, id)
+
+	return err
+}
+
+// Remove acknowledged deployment requests created before the given time, whether their deployments finished or not.
+// Returns the number of removed requests.
+func (db *database) DeleteAcknowledgedDeploymentRequests(ctx context.Context, createdBefore time.Time) (int64, error) {
+	query := `DELETE FROM deployment_request WHERE created < $1 AND acknowledged IS NOT NULL;`
+	tag, err := db.conn.Exec(ctx, query, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// Retrieve acknowledged deployment requests for a cluster, created after the given time, whose
+// deployments have not yet reached a final state. They are ordered by creation time.
+func (db *database) UnfinishedDeploymentRequests(ctx context.Context, cluster string, createdAfter time.Time) ([]DeploymentRequest, error) {
+	query := `
+SELECT ` + selectDeploymentRequestFields + ` FROM deployment_request
+WHERE cluster = $1 AND created > $2 AND acknowledged IS NOT NULL
+AND (
+    SELECT status FROM deployment_status
+    WHERE deployment_status.deployment_id = deployment_request.id
+    ORDER BY deployment_status.created DESC LIMIT 1
+) IN ('queued', 'pending', 'in_progress')
+ORDER BY created ASC;
+`
+	return db.deploymentRequests(ctx, query, cluster, createdAfter)
+}
+
+func (db *database) deploymentRequests(ctx context.Context, query string, args ...interface{}) ([]DeploymentRequest, error) {
+	rows, err := db.timedQuery(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]DeploymentRequest, 0)
+
+	defer rows.Close()
+	for rows.Next() {
+		request := DeploymentRequest{}
+
+		// see selectDeploymentRequestFields
+		err := rows.Scan(
+			&request.ID,
+			&request.Cluster,
+			&request.Payload,
+			&request.Created,
+			&request.Deadline,
+			&request.Delivered,
+			&request.Acknowledged,
+			&request.Instance,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
@@ -0,0 +1,34 @@
+package database_mapper
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
+)
+
+func DeploymentRequest(request pb.DeploymentRequest) (*database.DeploymentRequest, error) {
+	payload, err := proto.Marshal(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &database.DeploymentRequest{
+		ID:       request.GetDeliveryID(),
+		Cluster:  request.GetCluster(),
+		Payload:  payload,
+		Created:  request.Timestamp(),
+		Deadline: time.Unix(request.GetDeadline(), 0),
+	}, nil
+}
+
+func PbDeploymentRequest(request database.DeploymentRequest) (*pb.DeploymentRequest, error) {
+	pbRequest := &pb.DeploymentRequest{}
+	err := proto.Unmarshal(request.Payload, pbRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return pbRequest, nil
+}
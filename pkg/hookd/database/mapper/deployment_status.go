@@ -2,16 +2,42 @@ package database_mapper
 
 import (
 	"github.com/google/uuid"
-	"github.com/navikt/deployment/pkg/pb"
+	api_v1 "github.com/navikt/deployment/pkg/hookd/api/v1"
 	"github.com/navikt/deployment/pkg/hookd/database"
+	"github.com/navikt/deployment/pkg/pb"
 )
 
 func DeploymentStatus(status pb.DeploymentStatus) database.DeploymentStatus {
-	return database.DeploymentStatus{
+	dbStatus := database.DeploymentStatus{
 		ID:           uuid.New().String(),
 		DeploymentID: status.GetDeliveryID(),
 		Status:       status.GetState().String(),
 		Message:      status.GetDescription(),
 		Created:      status.Timestamp(),
 	}
+	// deployd assigns IDs to its statuses, so that statuses delivered more than once are only stored once.
+	if len(status.GetId()) > 0 {
+		dbStatus.ID = status.GetId()
+	}
+	if len(status.GetDiff()) > 0 {
+		diff := status.GetDiff()
+		dbStatus.Diff = &diff
+	}
+	for _, resource := range status.GetResources() {
+		dbStatus.Resources = append(dbStatus.Resources, ResourceStatus(*resource))
+	}
+	return dbStatus
+}
+
+func ResourceStatus(resource pb.ResourceStatus) api_v1.ResourceStatus {
+	return api_v1.ResourceStatus{
+		Group:          resource.GetGroup(),
+		Version:        resource.GetVersion(),
+		Kind:           resource.GetKind(),
+		Namespace:      resource.GetNamespace(),
+		Name:           resource.GetName(),
+		State:          resource.GetState().String(),
+		Message:        resource.GetMessage(),
+		DurationMillis: resource.GetDurationMillis(),
+	}
 }
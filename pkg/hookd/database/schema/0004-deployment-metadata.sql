@@ -0,0 +1,23 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Record where and what was deployed, and by whom.
+-- These fields are null for deployments made before this migration.
+ALTER TABLE deployment
+    ADD "cluster"     varchar null,
+    ADD "environment" varchar null,
+    ADD "ref"         varchar null,
+    ADD "deployer"    varchar null;
+
+-- Support listing deployments ordered by time, optionally filtered by team or repository.
+CREATE INDEX deployment_created_index ON deployment (created, id);
+CREATE INDEX deployment_team_index ON deployment (team, created);
+CREATE INDEX deployment_github_repository_index ON deployment (github_repository, created);
+
+-- Support looking up the current state of a deployment.
+CREATE INDEX deployment_status_deployment_id_index ON deployment_status (deployment_id, created);
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (4, now());
+COMMIT;
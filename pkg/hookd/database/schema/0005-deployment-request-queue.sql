@@ -0,0 +1,22 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Deployment requests that could not be delivered because the target cluster was offline.
+-- Requests are delivered in order of creation when the cluster reconnects, and then removed from the queue.
+-- The payload column holds a protobuf encoded DeploymentRequest.
+CREATE TABLE deployment_request
+(
+    "id"       varchar primary key references deployment (id) not null,
+    "cluster"  varchar                                         not null,
+    "payload"  bytea                                           not null,
+    "created"  timestamp with time zone                        not null,
+    "deadline" timestamp with time zone                        not null
+);
+
+CREATE INDEX deployment_request_cluster_index ON deployment_request (cluster, created);
+CREATE INDEX deployment_request_deadline_index ON deployment_request (deadline);
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (5, now());
+COMMIT;
@@ -0,0 +1,15 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Deployment requests are kept in the deployment_request table until acknowledged by deployd,
+-- so that they can be redelivered if deployd does not acknowledge them in time.
+-- The instance column holds the identifier of the deployd instance that last received the request.
+ALTER TABLE deployment_request
+    ADD "delivered"    timestamp with time zone null,
+    ADD "acknowledged" timestamp with time zone null,
+    ADD "instance"     varchar                  null;
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (6, now());
+COMMIT;
@@ -0,0 +1,17 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Dry runs are validated by the Kubernetes API server, but never applied.
+-- They are not synchronized to GitHub.
+ALTER TABLE deployment
+    ADD "dry_run" boolean not null default false;
+
+-- The final status of a dry run holds a unified diff between the live resources
+-- and the resources as they would look after the deployment.
+ALTER TABLE deployment_status
+    ADD "diff" text null;
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (7, now());
+COMMIT;
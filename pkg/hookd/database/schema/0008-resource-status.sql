@@ -0,0 +1,12 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Per-resource results of a deployment, stored as a JSON array. Each entry
+-- identifies a single Kubernetes resource and how far it got in the rollout.
+ALTER TABLE deployment_status
+    ADD "resources" jsonb null;
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (8, now());
+COMMIT;
@@ -0,0 +1,12 @@
+-- Run the entire migration as an atomic operation.
+START TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;
+
+-- Deployment requests are no longer removed as soon as deployd acknowledges them. They are kept until
+-- their deployment has finished, so that deployd can resume monitoring them after a restart.
+-- Acknowledged requests whose final status never arrives are removed by creation time.
+CREATE INDEX deployment_request_acknowledged_index ON deployment_request (created) WHERE acknowledged IS NOT NULL;
+
+-- Mark this database migration as completed.
+INSERT INTO migrations (version, created)
+VALUES (9, now());
+COMMIT;
@@ -6,4 +6,10 @@ var migrations = []string{
 	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Table apikey holds teams' deploy API keys.\n-- A team can have many API keys, with each key having its own expiry time.\nCREATE TABLE apikey\n(\n    \"key\"           varchar primary key      not null,\n    \"team\"          varchar                  not null,\n    \"team_azure_id\" varchar                  not null,\n    \"created\"       timestamp with time zone not null,\n    \"expires\"       timestamp with time zone null\n);\n\nCREATE INDEX apikey_team_index ON apikey (team);\nCREATE INDEX apikey_team_azure_id_index ON apikey (team_azure_id);\n\n-- Each row in the deployment table represents a single deployment request.\nCREATE TABLE deployment\n(\n    \"id\"                varchar primary key      not null,\n    \"team\"              varchar                  not null,\n    \"created\"           timestamp with time zone not null,\n    \"github_id\"         int unique               null,\n    \"github_repository\" varchar                  null\n);\n\n-- A row is recorded in deployment_status for each state change in a deployment.\nCREATE TABLE deployment_status\n(\n    \"id\"            varchar primary key                not null,\n    \"deployment_id\" varchar references deployment (id) not null,\n    \"status\"        varchar                            not null,\n    \"message\"       varchar                            not null,\n    \"github_id\"     int                                null,\n    \"created\"       timestamp with time zone           not null\n);\n\n-- Database migration\nCREATE TABLE migrations\n(\n    \"version\" int primary key          not null,\n    \"created\" timestamp with time zone not null\n);\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (1, now());\nCOMMIT;\n",
 	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Table team_repositories holds information about which repository can deploy to which team's resources.\n-- This supports the use of the legacy version in pkg/server/github_handler.go.\nCREATE TABLE team_repositories\n(\n    \"team\"       varchar not null,\n    \"repository\" varchar not null\n);\n\nCREATE INDEX team_repositories_team ON team_repositories (team);\nCREATE INDEX team_repositories_repository ON team_repositories (repository);\nCREATE UNIQUE INDEX team_repositories_unique ON team_repositories (team, repository);\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (2, now());\nCOMMIT;\n",
 	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- This field has never been used and we don't intend to use it anyway.\nALTER TABLE deployment_status\n    DROP github_id;\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (3, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Record where and what was deployed, and by whom.\n-- These fields are null for deployments made before this migration.\nALTER TABLE deployment\n    ADD \"cluster\"     varchar null,\n    ADD \"environment\" varchar null,\n    ADD \"ref\"         varchar null,\n    ADD \"deployer\"    varchar null;\n\n-- Support listing deployments ordered by time, optionally filtered by team or repository.\nCREATE INDEX deployment_created_index ON deployment (created, id);\nCREATE INDEX deployment_team_index ON deployment (team, created);\nCREATE INDEX deployment_github_repository_index ON deployment (github_repository, created);\n\n-- Support looking up the current state of a deployment.\nCREATE INDEX deployment_status_deployment_id_index ON deployment_status (deployment_id, created);\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (4, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Deployment requests that could not be delivered because the target cluster was offline.\n-- Requests are delivered in order of creation when the cluster reconnects, and then removed from the queue.\n-- The payload column holds a protobuf encoded DeploymentRequest.\nCREATE TABLE deployment_request\n(\n    \"id\"       varchar primary key references deployment (id) not null,\n    \"cluster\"  varchar                                         not null,\n    \"payload\"  bytea                                           not null,\n    \"created\"  timestamp with time zone                        not null,\n    \"deadline\" timestamp with time zone                        not null\n);\n\nCREATE INDEX deployment_request_cluster_index ON deployment_request (cluster, created);\nCREATE INDEX deployment_request_deadline_index ON deployment_request (deadline);\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (5, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Deployment requests are kept in the deployment_request table until acknowledged by deployd,\n-- so that they can be redelivered if deployd does not acknowledge them in time.\n-- The instance column holds the identifier of the deployd instance that last received the request.\nALTER TABLE deployment_request\n    ADD \"delivered\"    timestamp with time zone null,\n    ADD \"acknowledged\" timestamp with time zone null,\n    ADD \"instance\"     varchar                  null;\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (6, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Dry runs are validated by the Kubernetes API server, but never applied.\n-- They are not synchronized to GitHub.\nALTER TABLE deployment\n    ADD \"dry_run\" boolean not null default false;\n\n-- The final status of a dry run holds a unified diff between the live resources\n-- and the resources as they would look after the deployment.\nALTER TABLE deployment_status\n    ADD \"diff\" text null;\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (7, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Per-resource results of a deployment, stored as a JSON array. Each entry\n-- identifies a single Kubernetes resource and how far it got in the rollout.\nALTER TABLE deployment_status\n    ADD \"resources\" jsonb null;\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (8, now());\nCOMMIT;\n",
+	"-- Run the entire migration as an atomic operation.\nSTART TRANSACTION ISOLATION LEVEL SERIALIZABLE READ WRITE;\n\n-- Deployment requests are no longer removed as soon as deployd acknowledges them. They are kept until\n-- their deployment has finished, so that deployd can resume monitoring them after a restart.\n-- Acknowledged requests whose final status never arrives are removed by creation time.\nCREATE INDEX deployment_request_acknowledged_index ON deployment_request (created) WHERE acknowledged IS NOT NULL;\n\n-- Mark this database migration as completed.\nINSERT INTO migrations (version, created)\nVALUES (9, now());\nCOMMIT;\n",
 }
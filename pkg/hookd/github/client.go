@@ -89,7 +89,11 @@ func (c *client) CreateDeploymentStatus(ctx context.Context, status pb.Deploymen
 		return nil, ErrEmptyRepository
 	}
 
+	// GitHub has no notion of cancelled deployments, so they are reported as errors.
 	state := status.GetState().String()
+	if status.GetState() == pb.GithubDeploymentState_cancelled {
+		state = pb.GithubDeploymentState_error.String()
+	}
 	description := status.GetDescription()
 	if len(description) > maxDescriptionLength {
 		description = description[:maxDescriptionLength]
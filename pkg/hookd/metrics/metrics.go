@@ -70,6 +70,12 @@ func DatabaseQuery(t time.Time, err error) {
 	}).Observe(elapsed.Seconds())
 }
 
+func DeploymentRequestRedelivered(cluster string) {
+	redeliveries.With(prometheus.Labels{
+		Cluster: cluster,
+	}).Inc()
+}
+
 func UpdateQueue(status pb.DeploymentStatus) {
 	stateTransitions.With(prometheus.Labels{
 		LabelDeploymentState: status.GetState().String(),
@@ -80,7 +86,7 @@ func UpdateQueue(status pb.DeploymentStatus) {
 
 	switch status.GetState() {
 
-	// These three states are definite and signify the end of a deployment.
+	// These states are definite and signify the end of a deployment.
 	case pb.GithubDeploymentState_success:
 
 		// In case of successful deployment, report the lead time.
@@ -96,6 +102,8 @@ func UpdateQueue(status pb.DeploymentStatus) {
 	case pb.GithubDeploymentState_error:
 		fallthrough
 	case pb.GithubDeploymentState_failure:
+		fallthrough
+	case pb.GithubDeploymentState_cancelled:
 		delete(deployQueue, status.GetDeliveryID())
 
 	// Other states mean the deployment is still being processed.
@@ -164,6 +172,17 @@ var (
 		},
 	)
 
+	redeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "redeliveries",
+		Help:      "number of deployment requests redelivered because they were not acknowledged in time",
+		Namespace: namespace,
+		Subsystem: subsystem,
+	},
+		[]string{
+			Cluster,
+		},
+	)
+
 	leadTime = prometheus.NewSummaryVec(prometheus.SummaryOpts{
 		Name:      "lead_time_seconds",
 		Help:      "the time it takes from a deploy is made to it is running in the cluster",
@@ -186,6 +205,7 @@ func init() {
 	prometheus.MustRegister(queueSize)
 	prometheus.MustRegister(leadTime)
 	prometheus.MustRegister(clusterStatus)
+	prometheus.MustRegister(redeliveries)
 }
 
 func Handler() http.Handler {
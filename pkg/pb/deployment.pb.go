@@ -37,6 +37,7 @@ const (
 	GithubDeploymentState_in_progress GithubDeploymentState = 4
 	GithubDeploymentState_queued      GithubDeploymentState = 5
 	GithubDeploymentState_pending     GithubDeploymentState = 6
+	GithubDeploymentState_cancelled   GithubDeploymentState = 7
 )
 
 var GithubDeploymentState_name = map[int32]string{
@@ -47,6 +48,7 @@ var GithubDeploymentState_name = map[int32]string{
 	4: "in_progress",
 	5: "queued",
 	6: "pending",
+	7: "cancelled",
 }
 
 var GithubDeploymentState_value = map[string]int32{
@@ -57,6 +59,7 @@ var GithubDeploymentState_value = map[string]int32{
 	"in_progress": 4,
 	"queued":      5,
 	"pending":     6,
+	"cancelled":   7,
 }
 
 func (x GithubDeploymentState) String() string {
@@ -67,6 +70,37 @@ func (GithubDeploymentState) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_700de9d3ee114f41, []int{0}
 }
 
+type ResourceState int32
+
+const (
+	ResourceState_unapplied ResourceState = 0
+	ResourceState_applied   ResourceState = 1
+	ResourceState_failed    ResourceState = 2
+	ResourceState_healthy   ResourceState = 3
+)
+
+var ResourceState_name = map[int32]string{
+	0: "unapplied",
+	1: "applied",
+	2: "failed",
+	3: "healthy",
+}
+
+var ResourceState_value = map[string]int32{
+	"unapplied": 0,
+	"applied":   1,
+	"failed":    2,
+	"healthy":   3,
+}
+
+func (x ResourceState) String() string {
+	return proto.EnumName(ResourceState_name, int32(x))
+}
+
+func (ResourceState) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_700de9d3ee114f41, []int{1}
+}
+
 type GithubRepository struct {
 	Owner                string   `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
 	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
@@ -272,16 +306,22 @@ func (m *Payload) GetKubernetes() *Kubernetes {
 }
 
 type DeploymentRequest struct {
-	Deployment           *DeploymentSpec      `protobuf:"bytes,1,opt,name=deployment,proto3" json:"deployment,omitempty"`
-	Deadline             int64                `protobuf:"varint,3,opt,name=deadline,proto3" json:"deadline,omitempty"`
-	Cluster              string               `protobuf:"bytes,5,opt,name=cluster,proto3" json:"cluster,omitempty"`
-	DeliveryID           string               `protobuf:"bytes,6,opt,name=deliveryID,proto3" json:"deliveryID,omitempty"`
-	PayloadSpec          *Payload             `protobuf:"bytes,7,opt,name=payloadSpec,proto3" json:"payloadSpec,omitempty"`
-	Time                 *timestamp.Timestamp `protobuf:"bytes,8,opt,name=time,proto3" json:"time,omitempty"`
-	Status               *DeploymentStatus    `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	Deployment            *DeploymentSpec      `protobuf:"bytes,1,opt,name=deployment,proto3" json:"deployment,omitempty"`
+	Deadline              int64                `protobuf:"varint,3,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Cluster               string               `protobuf:"bytes,5,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	DeliveryID            string               `protobuf:"bytes,6,opt,name=deliveryID,proto3" json:"deliveryID,omitempty"`
+	PayloadSpec           *Payload             `protobuf:"bytes,7,opt,name=payloadSpec,proto3" json:"payloadSpec,omitempty"`
+	Time                  *timestamp.Timestamp `protobuf:"bytes,8,opt,name=time,proto3" json:"time,omitempty"`
+	Status                *DeploymentStatus    `protobuf:"bytes,9,opt,name=status,proto3" json:"status,omitempty"`
+	Cancel                bool                 `protobuf:"varint,10,opt,name=cancel,proto3" json:"cancel,omitempty"`
+	DryRun                bool                 `protobuf:"varint,11,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	Rollback              bool                 `protobuf:"varint,12,opt,name=rollback,proto3" json:"rollback,omitempty"`
+	Inventory             string               `protobuf:"bytes,13,opt,name=inventory,proto3" json:"inventory,omitempty"`
+	Prune                 bool                 `protobuf:"varint,14,opt,name=prune,proto3" json:"prune,omitempty"`
+	RolloutTimeoutSeconds int64                `protobuf:"varint,15,opt,name=rolloutTimeoutSeconds,proto3" json:"rolloutTimeoutSeconds,omitempty"`
+	XXX_NoUnkeyedLiteral  struct{}             `json:"-"`
+	XXX_unrecognized      []byte               `json:"-"`
+	XXX_sizecache         int32                `json:"-"`
 }
 
 func (m *DeploymentRequest) Reset()         { *m = DeploymentRequest{} }
@@ -358,6 +398,48 @@ func (m *DeploymentRequest) GetStatus() *DeploymentStatus {
 	return nil
 }
 
+func (m *DeploymentRequest) GetCancel() bool {
+	if m != nil {
+		return m.Cancel
+	}
+	return false
+}
+
+func (m *DeploymentRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+func (m *DeploymentRequest) GetRollback() bool {
+	if m != nil {
+		return m.Rollback
+	}
+	return false
+}
+
+func (m *DeploymentRequest) GetInventory() string {
+	if m != nil {
+		return m.Inventory
+	}
+	return ""
+}
+
+func (m *DeploymentRequest) GetPrune() bool {
+	if m != nil {
+		return m.Prune
+	}
+	return false
+}
+
+func (m *DeploymentRequest) GetRolloutTimeoutSeconds() int64 {
+	if m != nil {
+		return m.RolloutTimeoutSeconds
+	}
+	return 0
+}
+
 type DeploymentStatus struct {
 	Deployment           *DeploymentSpec       `protobuf:"bytes,1,opt,name=deployment,proto3" json:"deployment,omitempty"`
 	State                GithubDeploymentState `protobuf:"varint,2,opt,name=state,proto3,enum=deployment.GithubDeploymentState" json:"state,omitempty"`
@@ -367,6 +449,9 @@ type DeploymentStatus struct {
 	Cluster              string                `protobuf:"bytes,6,opt,name=cluster,proto3" json:"cluster,omitempty"`
 	Time                 *timestamp.Timestamp  `protobuf:"bytes,8,opt,name=time,proto3" json:"time,omitempty"`
 	Id                   string                `protobuf:"bytes,9,opt,name=id,proto3" json:"id,omitempty"`
+	Diff                 string                `protobuf:"bytes,10,opt,name=diff,proto3" json:"diff,omitempty"`
+	Progress             bool                  `protobuf:"varint,11,opt,name=progress,proto3" json:"progress,omitempty"`
+	Resources            []*ResourceStatus     `protobuf:"bytes,12,rep,name=resources,proto3" json:"resources,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
 	XXX_unrecognized     []byte                `json:"-"`
 	XXX_sizecache        int32                 `json:"-"`
@@ -453,6 +538,122 @@ func (m *DeploymentStatus) GetId() string {
 	return ""
 }
 
+func (m *DeploymentStatus) GetDiff() string {
+	if m != nil {
+		return m.Diff
+	}
+	return ""
+}
+
+func (m *DeploymentStatus) GetProgress() bool {
+	if m != nil {
+		return m.Progress
+	}
+	return false
+}
+
+func (m *DeploymentStatus) GetResources() []*ResourceStatus {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
+type ResourceStatus struct {
+	Group                string        `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Version              string        `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Kind                 string        `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	Namespace            string        `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name                 string        `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	State                ResourceState `protobuf:"varint,6,opt,name=state,proto3,enum=deployment.ResourceState" json:"state,omitempty"`
+	Message              string        `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	DurationMillis       int64         `protobuf:"varint,8,opt,name=durationMillis,proto3" json:"durationMillis,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ResourceStatus) Reset()         { *m = ResourceStatus{} }
+func (m *ResourceStatus) String() string { return proto.CompactTextString(m) }
+func (*ResourceStatus) ProtoMessage()    {}
+func (*ResourceStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_700de9d3ee114f41, []int{6}
+}
+
+func (m *ResourceStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResourceStatus.Unmarshal(m, b)
+}
+func (m *ResourceStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResourceStatus.Marshal(b, m, deterministic)
+}
+func (m *ResourceStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResourceStatus.Merge(m, src)
+}
+func (m *ResourceStatus) XXX_Size() int {
+	return xxx_messageInfo_ResourceStatus.Size(m)
+}
+func (m *ResourceStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResourceStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResourceStatus proto.InternalMessageInfo
+
+func (m *ResourceStatus) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetState() ResourceState {
+	if m != nil {
+		return m.State
+	}
+	return ResourceState_unapplied
+}
+
+func (m *ResourceStatus) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *ResourceStatus) GetDurationMillis() int64 {
+	if m != nil {
+		return m.DurationMillis
+	}
+	return 0
+}
+
 type SignedMessage struct {
 	Message              []byte   `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
 	Signature            []byte   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
@@ -465,7 +666,7 @@ func (m *SignedMessage) Reset()         { *m = SignedMessage{} }
 func (m *SignedMessage) String() string { return proto.CompactTextString(m) }
 func (*SignedMessage) ProtoMessage()    {}
 func (*SignedMessage) Descriptor() ([]byte, []int) {
-	return fileDescriptor_700de9d3ee114f41, []int{6}
+	return fileDescriptor_700de9d3ee114f41, []int{7}
 }
 
 func (m *SignedMessage) XXX_Unmarshal(b []byte) error {
@@ -502,6 +703,7 @@ func (m *SignedMessage) GetSignature() []byte {
 
 type GetDeploymentOpts struct {
 	Cluster              string   `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Instance             string   `protobuf:"bytes,2,opt,name=instance,proto3" json:"instance,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -511,7 +713,7 @@ func (m *GetDeploymentOpts) Reset()         { *m = GetDeploymentOpts{} }
 func (m *GetDeploymentOpts) String() string { return proto.CompactTextString(m) }
 func (*GetDeploymentOpts) ProtoMessage()    {}
 func (*GetDeploymentOpts) Descriptor() ([]byte, []int) {
-	return fileDescriptor_700de9d3ee114f41, []int{7}
+	return fileDescriptor_700de9d3ee114f41, []int{8}
 }
 
 func (m *GetDeploymentOpts) XXX_Unmarshal(b []byte) error {
@@ -539,6 +741,52 @@ func (m *GetDeploymentOpts) GetCluster() string {
 	return ""
 }
 
+func (m *GetDeploymentOpts) GetInstance() string {
+	if m != nil {
+		return m.Instance
+	}
+	return ""
+}
+
+type DeploymentRequests struct {
+	Requests             []*DeploymentRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *DeploymentRequests) Reset()         { *m = DeploymentRequests{} }
+func (m *DeploymentRequests) String() string { return proto.CompactTextString(m) }
+func (*DeploymentRequests) ProtoMessage()    {}
+func (*DeploymentRequests) Descriptor() ([]byte, []int) {
+	return fileDescriptor_700de9d3ee114f41, []int{9}
+}
+
+func (m *DeploymentRequests) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeploymentRequests.Unmarshal(m, b)
+}
+func (m *DeploymentRequests) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeploymentRequests.Marshal(b, m, deterministic)
+}
+func (m *DeploymentRequests) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeploymentRequests.Merge(m, src)
+}
+func (m *DeploymentRequests) XXX_Size() int {
+	return xxx_messageInfo_DeploymentRequests.Size(m)
+}
+func (m *DeploymentRequests) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeploymentRequests.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeploymentRequests proto.InternalMessageInfo
+
+func (m *DeploymentRequests) GetRequests() []*DeploymentRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
 type ReportStatusOpts struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -549,7 +797,7 @@ func (m *ReportStatusOpts) Reset()         { *m = ReportStatusOpts{} }
 func (m *ReportStatusOpts) String() string { return proto.CompactTextString(m) }
 func (*ReportStatusOpts) ProtoMessage()    {}
 func (*ReportStatusOpts) Descriptor() ([]byte, []int) {
-	return fileDescriptor_700de9d3ee114f41, []int{8}
+	return fileDescriptor_700de9d3ee114f41, []int{10}
 }
 
 func (m *ReportStatusOpts) XXX_Unmarshal(b []byte) error {
@@ -570,17 +818,108 @@ func (m *ReportStatusOpts) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ReportStatusOpts proto.InternalMessageInfo
 
+type Acknowledgement struct {
+	DeliveryID           string   `protobuf:"bytes,1,opt,name=deliveryID,proto3" json:"deliveryID,omitempty"`
+	Cluster              string   `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Instance             string   `protobuf:"bytes,3,opt,name=instance,proto3" json:"instance,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Acknowledgement) Reset()         { *m = Acknowledgement{} }
+func (m *Acknowledgement) String() string { return proto.CompactTextString(m) }
+func (*Acknowledgement) ProtoMessage()    {}
+func (*Acknowledgement) Descriptor() ([]byte, []int) {
+	return fileDescriptor_700de9d3ee114f41, []int{11}
+}
+
+func (m *Acknowledgement) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Acknowledgement.Unmarshal(m, b)
+}
+func (m *Acknowledgement) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Acknowledgement.Marshal(b, m, deterministic)
+}
+func (m *Acknowledgement) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Acknowledgement.Merge(m, src)
+}
+func (m *Acknowledgement) XXX_Size() int {
+	return xxx_messageInfo_Acknowledgement.Size(m)
+}
+func (m *Acknowledgement) XXX_DiscardUnknown() {
+	xxx_messageInfo_Acknowledgement.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Acknowledgement proto.InternalMessageInfo
+
+func (m *Acknowledgement) GetDeliveryID() string {
+	if m != nil {
+		return m.DeliveryID
+	}
+	return ""
+}
+
+func (m *Acknowledgement) GetCluster() string {
+	if m != nil {
+		return m.Cluster
+	}
+	return ""
+}
+
+func (m *Acknowledgement) GetInstance() string {
+	if m != nil {
+		return m.Instance
+	}
+	return ""
+}
+
+type AcknowledgeOpts struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AcknowledgeOpts) Reset()         { *m = AcknowledgeOpts{} }
+func (m *AcknowledgeOpts) String() string { return proto.CompactTextString(m) }
+func (*AcknowledgeOpts) ProtoMessage()    {}
+func (*AcknowledgeOpts) Descriptor() ([]byte, []int) {
+	return fileDescriptor_700de9d3ee114f41, []int{12}
+}
+
+func (m *AcknowledgeOpts) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AcknowledgeOpts.Unmarshal(m, b)
+}
+func (m *AcknowledgeOpts) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AcknowledgeOpts.Marshal(b, m, deterministic)
+}
+func (m *AcknowledgeOpts) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AcknowledgeOpts.Merge(m, src)
+}
+func (m *AcknowledgeOpts) XXX_Size() int {
+	return xxx_messageInfo_AcknowledgeOpts.Size(m)
+}
+func (m *AcknowledgeOpts) XXX_DiscardUnknown() {
+	xxx_messageInfo_AcknowledgeOpts.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AcknowledgeOpts proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterEnum("deployment.GithubDeploymentState", GithubDeploymentState_name, GithubDeploymentState_value)
+	proto.RegisterEnum("deployment.ResourceState", ResourceState_name, ResourceState_value)
 	proto.RegisterType((*GithubRepository)(nil), "deployment.GithubRepository")
 	proto.RegisterType((*DeploymentSpec)(nil), "deployment.DeploymentSpec")
 	proto.RegisterType((*Kubernetes)(nil), "deployment.Kubernetes")
 	proto.RegisterType((*Payload)(nil), "deployment.Payload")
 	proto.RegisterType((*DeploymentRequest)(nil), "deployment.DeploymentRequest")
 	proto.RegisterType((*DeploymentStatus)(nil), "deployment.DeploymentStatus")
+	proto.RegisterType((*ResourceStatus)(nil), "deployment.ResourceStatus")
 	proto.RegisterType((*SignedMessage)(nil), "deployment.SignedMessage")
 	proto.RegisterType((*GetDeploymentOpts)(nil), "deployment.GetDeploymentOpts")
+	proto.RegisterType((*DeploymentRequests)(nil), "deployment.DeploymentRequests")
 	proto.RegisterType((*ReportStatusOpts)(nil), "deployment.ReportStatusOpts")
+	proto.RegisterType((*Acknowledgement)(nil), "deployment.Acknowledgement")
+	proto.RegisterType((*AcknowledgeOpts)(nil), "deployment.AcknowledgeOpts")
 }
 
 func init() {
@@ -588,53 +927,76 @@ func init() {
 }
 
 var fileDescriptor_700de9d3ee114f41 = []byte{
-	// 723 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xdb, 0x6e, 0xdb, 0x38,
-	0x10, 0x8d, 0x6c, 0xc9, 0x97, 0x91, 0x37, 0xab, 0x70, 0x6f, 0x82, 0x37, 0xd9, 0xcd, 0xea, 0x29,
-	0x58, 0xa0, 0x4e, 0xe1, 0x36, 0x2d, 0x50, 0xe4, 0xa9, 0x0d, 0x10, 0x24, 0x6d, 0x91, 0x82, 0xe9,
-	0x7b, 0x21, 0x4b, 0x13, 0x97, 0xa8, 0x4d, 0x2a, 0x24, 0xe5, 0xc2, 0x3f, 0xd4, 0xfe, 0x4a, 0xbf,
-	0xa3, 0x5f, 0x52, 0x90, 0x92, 0x2d, 0xda, 0x09, 0x0a, 0x14, 0x7d, 0x9b, 0x39, 0x3c, 0xe4, 0xcc,
-	0x9c, 0x39, 0x12, 0xfc, 0x9d, 0x63, 0x31, 0x13, 0xcb, 0x39, 0x72, 0x7d, 0xdc, 0x84, 0xa3, 0x42,
-	0x0a, 0x2d, 0x08, 0x34, 0xc8, 0xf0, 0xdf, 0xa9, 0x10, 0xd3, 0x19, 0x1e, 0xdb, 0x93, 0x49, 0x79,
-	0x73, 0xac, 0xd9, 0x1c, 0x95, 0x4e, 0xe7, 0x45, 0x45, 0x1e, 0xee, 0x6f, 0x13, 0x94, 0x96, 0x65,
-	0x56, 0x3f, 0x95, 0x9c, 0x42, 0x74, 0xce, 0xf4, 0xfb, 0x72, 0x42, 0xb1, 0x10, 0x8a, 0x69, 0x21,
-	0x97, 0xe4, 0x77, 0x08, 0xc4, 0x47, 0x8e, 0x32, 0xf6, 0x0e, 0xbd, 0xa3, 0x3e, 0xad, 0x12, 0x42,
-	0xc0, 0xe7, 0xe9, 0x1c, 0xe3, 0x96, 0x05, 0x6d, 0x9c, 0x7c, 0xf2, 0x60, 0xf7, 0x6c, 0xdd, 0xcb,
-	0x75, 0x81, 0x19, 0x39, 0x05, 0x90, 0xeb, 0xa7, 0xec, 0x0b, 0xe1, 0x78, 0x7f, 0xe4, 0x8c, 0xb0,
-	0x5d, 0x8e, 0x3a, 0x7c, 0x92, 0xc0, 0xa0, 0xa1, 0x5e, 0x9c, 0xd9, 0x62, 0x6d, 0xba, 0x81, 0x91,
-	0x43, 0x08, 0x91, 0x2f, 0x98, 0x14, 0xdc, 0x00, 0x71, 0xdb, 0xf6, 0xe3, 0x42, 0x24, 0x82, 0xb6,
-	0xc4, 0x9b, 0xd8, 0xb7, 0x27, 0x26, 0x4c, 0x5e, 0x00, 0xbc, 0x2c, 0x27, 0x28, 0x39, 0x6a, 0x54,
-	0xe4, 0x04, 0xfa, 0x12, 0x95, 0x28, 0x65, 0x86, 0x2a, 0xf6, 0x0e, 0xdb, 0x47, 0xe1, 0xf8, 0xaf,
-	0x51, 0x25, 0xd3, 0x68, 0x25, 0xd3, 0xe8, 0xda, 0xca, 0x44, 0x1b, 0x66, 0x22, 0xa0, 0xfb, 0x26,
-	0x5d, 0xce, 0x44, 0x9a, 0x93, 0x18, 0xba, 0x0b, 0x94, 0x8a, 0x09, 0x6e, 0xef, 0x07, 0x74, 0x95,
-	0x1a, 0x99, 0x34, 0xa6, 0xf3, 0x95, 0x4c, 0x26, 0x26, 0x4f, 0x00, 0x3e, 0xac, 0xab, 0xdb, 0x86,
-	0xc3, 0xf1, 0x9f, 0xae, 0x26, 0x4d, 0x6f, 0xd4, 0x61, 0x26, 0x5f, 0x5b, 0xb0, 0xd7, 0xc8, 0x4b,
-	0xf1, 0xb6, 0x44, 0xa5, 0xc9, 0x33, 0x70, 0xf6, 0x5f, 0x2b, 0x3c, 0x74, 0x5f, 0xdb, 0xdc, 0x08,
-	0x75, 0xd8, 0x64, 0x08, 0xbd, 0x1c, 0xd3, 0x7c, 0xc6, 0x38, 0xda, 0x3e, 0xda, 0x74, 0x9d, 0x9b,
-	0x99, 0xb2, 0x59, 0xa9, 0x34, 0xca, 0x38, 0xb0, 0xcd, 0xaf, 0x52, 0xf2, 0x8f, 0xa9, 0x38, 0x63,
-	0x0b, 0x94, 0xcb, 0x8b, 0xb3, 0xb8, 0x63, 0x0f, 0x1d, 0x84, 0x9c, 0x40, 0x58, 0x54, 0xc2, 0x98,
-	0x82, 0x71, 0xd7, 0xb6, 0xf4, 0x9b, 0xdb, 0x52, 0xad, 0x1b, 0x75, 0x79, 0x64, 0x04, 0xbe, 0x31,
-	0x6b, 0xdc, 0xab, 0x47, 0xd8, 0xde, 0xc0, 0xdb, 0x95, 0x93, 0xa9, 0xe5, 0x91, 0xc7, 0xd0, 0x51,
-	0x3a, 0xd5, 0xa5, 0x8a, 0xfb, 0x77, 0x6d, 0xe5, 0x0c, 0x6d, 0x39, 0xb4, 0xe6, 0x5e, 0xfa, 0xbd,
-	0x56, 0xd4, 0xbe, 0xf4, 0x7b, 0x7e, 0x14, 0xd0, 0xfe, 0xfa, 0xe3, 0xa0, 0xdd, 0xba, 0x93, 0xe4,
-	0x4b, 0x0b, 0xa2, 0xed, 0xcb, 0x3f, 0xa5, 0xf1, 0x53, 0x08, 0x4c, 0xe9, 0xea, 0x4b, 0xd9, 0x1d,
-	0xff, 0x77, 0xd7, 0xfc, 0x9b, 0xe5, 0x90, 0x56, 0x7c, 0x63, 0xec, 0x1c, 0x55, 0x26, 0x59, 0xa1,
-	0x8d, 0xb1, 0x6a, 0x63, 0x3b, 0xd0, 0xd6, 0x22, 0xfc, 0x3b, 0x8b, 0x58, 0x99, 0x2f, 0x70, 0xcc,
-	0xe7, 0xac, 0xb5, 0xb3, 0xb9, 0xd6, 0x1f, 0xd5, 0x7f, 0x17, 0x5a, 0x2c, 0xb7, 0xda, 0xf7, 0x69,
-	0x8b, 0xe5, 0x97, 0x7e, 0xaf, 0x1b, 0xf5, 0x1c, 0x4d, 0x93, 0x73, 0xf8, 0xe5, 0x9a, 0x4d, 0x39,
-	0xe6, 0xaf, 0x51, 0xa9, 0x74, 0x6a, 0x2d, 0x35, 0xaf, 0x42, 0xab, 0xe1, 0x80, 0xae, 0x52, 0xb2,
-	0x0f, 0x7d, 0xc5, 0xa6, 0x3c, 0xd5, 0xa5, 0xac, 0x84, 0x1a, 0xd0, 0x06, 0x48, 0x1e, 0xc0, 0xde,
-	0x39, 0xea, 0x46, 0xa6, 0xab, 0x42, 0x2b, 0x77, 0x10, 0x6f, 0x63, 0x90, 0x84, 0x40, 0x64, 0xfe,
-	0x27, 0xb2, 0xde, 0x9e, 0x61, 0xff, 0xaf, 0xe1, 0x8f, 0x7b, 0xc5, 0x26, 0x21, 0x74, 0x55, 0x99,
-	0x65, 0xa8, 0x54, 0xb4, 0x43, 0xfa, 0x10, 0xa0, 0x94, 0x42, 0x46, 0x9e, 0xc1, 0x6f, 0x52, 0x36,
-	0x2b, 0x25, 0x46, 0x2d, 0x32, 0x80, 0x1e, 0xe3, 0x69, 0xa6, 0xd9, 0x02, 0xa3, 0x36, 0xf9, 0x15,
-	0x42, 0xc6, 0xdf, 0x15, 0x52, 0x4c, 0xa5, 0xb9, 0xe6, 0x13, 0x80, 0xce, 0x6d, 0x89, 0x25, 0xe6,
-	0x51, 0x60, 0xee, 0x15, 0xc8, 0x73, 0xc6, 0xa7, 0x51, 0x67, 0xfc, 0xd9, 0x83, 0x4e, 0x55, 0x90,
-	0x5c, 0x41, 0xd8, 0x94, 0x56, 0xe4, 0x60, 0xc3, 0x06, 0xdb, 0xc3, 0x0d, 0x0f, 0xee, 0x37, 0x57,
-	0xfd, 0xcd, 0x27, 0x3b, 0x0f, 0x3d, 0xf2, 0x0a, 0x06, 0xee, 0x94, 0xe4, 0xbb, 0xf6, 0x1f, 0x6e,
-	0x9c, 0x6e, 0xab, 0x93, 0xec, 0x3c, 0x1f, 0x42, 0xcc, 0xc5, 0x88, 0xa7, 0x8b, 0x6a, 0xdf, 0xca,
-	0xa1, 0x4f, 0x3a, 0x16, 0x7a, 0xf4, 0x2d, 0x00, 0x00, 0xff, 0xff, 0x32, 0x3f, 0x38, 0x39, 0x85,
-	0x06, 0x00, 0x00,
+	// 1099 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xcd, 0x8e, 0xdb, 0x36,
+	0x10, 0x8e, 0x2c, 0xff, 0x8e, 0xbd, 0x8e, 0xc2, 0x36, 0xa9, 0xea, 0x6c, 0xd3, 0xad, 0x0e, 0x41,
+	0x90, 0x83, 0x5d, 0x6c, 0x93, 0xfe, 0xe6, 0xd2, 0x74, 0x81, 0x45, 0xb6, 0x08, 0x52, 0x70, 0xdb,
+	0x1e, 0x7a, 0x29, 0x64, 0x69, 0xac, 0x25, 0x2c, 0x93, 0x0a, 0x29, 0x39, 0xf0, 0xb9, 0x2f, 0xd1,
+	0x27, 0xe8, 0x03, 0xf4, 0x19, 0xfa, 0x52, 0xbd, 0x15, 0xa4, 0x64, 0x8b, 0xb2, 0x77, 0x17, 0x08,
+	0x7a, 0xe3, 0x0c, 0x87, 0xe4, 0xcc, 0x37, 0xdf, 0x37, 0x12, 0x3c, 0x8c, 0x31, 0x4b, 0xc5, 0x66,
+	0x85, 0x3c, 0x9f, 0xd5, 0xcb, 0x69, 0x26, 0x45, 0x2e, 0x08, 0xd4, 0x9e, 0xc9, 0xa7, 0x89, 0x10,
+	0x49, 0x8a, 0x33, 0xb3, 0x33, 0x2f, 0x16, 0xb3, 0x9c, 0xad, 0x50, 0xe5, 0xe1, 0x2a, 0x2b, 0x83,
+	0x27, 0xc7, 0xfb, 0x01, 0x2a, 0x97, 0x45, 0x54, 0x5d, 0x15, 0xbc, 0x00, 0xef, 0x9c, 0xe5, 0x57,
+	0xc5, 0x9c, 0x62, 0x26, 0x14, 0xcb, 0x85, 0xdc, 0x90, 0x0f, 0xa1, 0x23, 0xde, 0x71, 0x94, 0xbe,
+	0x73, 0xe2, 0x3c, 0x19, 0xd0, 0xd2, 0x20, 0x04, 0xda, 0x3c, 0x5c, 0xa1, 0xdf, 0x32, 0x4e, 0xb3,
+	0x0e, 0xfe, 0x72, 0x60, 0x7c, 0xb6, 0xcb, 0xe5, 0x32, 0xc3, 0x88, 0xbc, 0x00, 0x90, 0xbb, 0xab,
+	0xcc, 0x0d, 0xc3, 0xd3, 0xe3, 0xa9, 0x55, 0xc2, 0xfe, 0x73, 0xd4, 0x8a, 0x27, 0x01, 0x8c, 0xea,
+	0xd0, 0x57, 0x67, 0xe6, 0x31, 0x97, 0x36, 0x7c, 0xe4, 0x04, 0x86, 0xc8, 0xd7, 0x4c, 0x0a, 0xae,
+	0x1d, 0xbe, 0x6b, 0xf2, 0xb1, 0x5d, 0xc4, 0x03, 0x57, 0xe2, 0xc2, 0x6f, 0x9b, 0x1d, 0xbd, 0x0c,
+	0x7e, 0x00, 0xf8, 0xb1, 0x98, 0xa3, 0xe4, 0x98, 0xa3, 0x22, 0xcf, 0x61, 0x20, 0x51, 0x89, 0x42,
+	0x46, 0xa8, 0x7c, 0xe7, 0xc4, 0x7d, 0x32, 0x3c, 0xfd, 0x68, 0x5a, 0xc2, 0x34, 0xdd, 0xc2, 0x34,
+	0xbd, 0x34, 0x30, 0xd1, 0x3a, 0x32, 0x10, 0xd0, 0xfb, 0x29, 0xdc, 0xa4, 0x22, 0x8c, 0x89, 0x0f,
+	0xbd, 0x35, 0x4a, 0xc5, 0x04, 0x37, 0xe7, 0x3b, 0x74, 0x6b, 0x6a, 0x98, 0x72, 0x0c, 0x57, 0x5b,
+	0x98, 0xf4, 0x9a, 0x7c, 0x09, 0xb0, 0xdc, 0xbd, 0x6e, 0x12, 0x1e, 0x9e, 0x3e, 0xb0, 0x31, 0xa9,
+	0x73, 0xa3, 0x56, 0x64, 0xf0, 0x67, 0x1b, 0xee, 0xd5, 0xf0, 0x52, 0x7c, 0x5b, 0xa0, 0xca, 0xc9,
+	0xb7, 0x60, 0xf5, 0xbf, 0x42, 0x78, 0x62, 0xdf, 0xd6, 0xec, 0x08, 0xb5, 0xa2, 0xc9, 0x04, 0xfa,
+	0x31, 0x86, 0x71, 0xca, 0x38, 0x9a, 0x3c, 0x5c, 0xba, 0xb3, 0x75, 0x4d, 0x51, 0x5a, 0xa8, 0x1c,
+	0xa5, 0xdf, 0x31, 0xc9, 0x6f, 0x4d, 0xf2, 0x48, 0xbf, 0x98, 0xb2, 0x35, 0xca, 0xcd, 0xab, 0x33,
+	0xbf, 0x6b, 0x36, 0x2d, 0x0f, 0x79, 0x0e, 0xc3, 0xac, 0x04, 0x46, 0x3f, 0xe8, 0xf7, 0x4c, 0x4a,
+	0x1f, 0xd8, 0x29, 0x55, 0xb8, 0x51, 0x3b, 0x8e, 0x4c, 0xa1, 0xad, 0xc9, 0xea, 0xf7, 0xab, 0x12,
+	0xf6, 0x3b, 0xf0, 0xf3, 0x96, 0xc9, 0xd4, 0xc4, 0x91, 0x67, 0xd0, 0x55, 0x79, 0x98, 0x17, 0xca,
+	0x1f, 0x1c, 0xd2, 0xca, 0x2a, 0xda, 0xc4, 0xd0, 0x2a, 0x96, 0x3c, 0x80, 0x6e, 0x14, 0xf2, 0x08,
+	0x53, 0x1f, 0x4e, 0x9c, 0x27, 0x7d, 0x5a, 0x59, 0xda, 0x1f, 0xcb, 0x0d, 0x2d, 0xb8, 0x3f, 0x2c,
+	0xfd, 0xa5, 0xa5, 0x21, 0x92, 0x22, 0x4d, 0xe7, 0x61, 0xb4, 0xf4, 0x47, 0x66, 0x67, 0x67, 0x93,
+	0x63, 0x18, 0x30, 0xbe, 0x46, 0x6e, 0xb8, 0x7d, 0x64, 0x70, 0xa8, 0x1d, 0x5a, 0x37, 0x99, 0x2c,
+	0x38, 0xfa, 0x63, 0x73, 0xac, 0x34, 0xc8, 0x33, 0xb8, 0xaf, 0xcf, 0x8b, 0x22, 0xd7, 0xf5, 0x88,
+	0x22, 0xbf, 0xc4, 0x48, 0xf0, 0x58, 0xf9, 0x77, 0x0d, 0xfe, 0xd7, 0x6f, 0x5e, 0xb4, 0xfb, 0x2d,
+	0xcf, 0xbd, 0x68, 0xf7, 0xdb, 0x5e, 0x87, 0x0e, 0x76, 0x92, 0xa6, 0xbd, 0x0a, 0xbf, 0xe0, 0x6f,
+	0x17, 0xbc, 0xfd, 0x92, 0xff, 0x17, 0x33, 0xbe, 0x82, 0x8e, 0x06, 0xac, 0xd4, 0xf7, 0xf8, 0xf4,
+	0xb3, 0x43, 0xc9, 0x36, 0x9f, 0x43, 0x5a, 0xc6, 0x6b, 0x39, 0xc6, 0xa8, 0x22, 0xc9, 0xb2, 0x5c,
+	0xcb, 0xa1, 0x92, 0xa3, 0xe5, 0xda, 0xa3, 0x4f, 0xfb, 0x80, 0x3e, 0x5b, 0xc9, 0x74, 0x2c, 0xc9,
+	0x58, 0x64, 0xec, 0x36, 0xc9, 0xf8, 0xbe, 0xac, 0x19, 0x43, 0x8b, 0xc5, 0x86, 0x31, 0x03, 0xda,
+	0x62, 0xb1, 0x7e, 0x2d, 0x66, 0x8b, 0x85, 0x61, 0xc3, 0x80, 0x9a, 0xb5, 0xee, 0x79, 0x26, 0x45,
+	0x22, 0x51, 0xa9, 0x8a, 0x0d, 0x3b, 0x9b, 0x7c, 0x6d, 0x0f, 0x8b, 0xd1, 0x89, 0xbb, 0x8f, 0x29,
+	0xad, 0x36, 0x2b, 0xda, 0xd5, 0xc1, 0x17, 0xed, 0x7e, 0xcf, 0xeb, 0x5b, 0xdd, 0x0b, 0xfe, 0x75,
+	0x60, 0xdc, 0x0c, 0xd7, 0x9c, 0x49, 0xa4, 0x28, 0xb2, 0xed, 0xac, 0x35, 0x86, 0x3d, 0x5e, 0xca,
+	0x39, 0x62, 0x8f, 0x97, 0x25, 0xe3, 0x71, 0x05, 0xb3, 0x59, 0x6b, 0x56, 0xea, 0x69, 0xac, 0xb2,
+	0x30, 0xc2, 0x0a, 0xde, 0xda, 0xb1, 0x9b, 0xdb, 0x9d, 0x7a, 0x6e, 0x93, 0xd9, 0xb6, 0xd9, 0x5d,
+	0xd3, 0xec, 0x8f, 0x6f, 0xaa, 0x67, 0xd7, 0x64, 0x1f, 0x7a, 0x2b, 0x54, 0x2a, 0x4c, 0xd0, 0xa8,
+	0x7b, 0x40, 0xb7, 0x26, 0x79, 0x0c, 0xe3, 0xb8, 0x90, 0xa1, 0x6e, 0xf4, 0x6b, 0x96, 0xa6, 0x4c,
+	0x99, 0xc6, 0xb8, 0x74, 0xcf, 0x1b, 0x9c, 0xc3, 0xd1, 0x25, 0x4b, 0x38, 0xc6, 0xaf, 0xab, 0x83,
+	0xd6, 0x95, 0xba, 0xf6, 0x51, 0x7d, 0xe5, 0x31, 0x0c, 0x14, 0x4b, 0x78, 0x98, 0x17, 0xb2, 0xa4,
+	0xe3, 0x88, 0xd6, 0x8e, 0xe0, 0x15, 0xdc, 0x3b, 0xc7, 0xbc, 0x26, 0xe3, 0x9b, 0x2c, 0x57, 0x36,
+	0x5d, 0x9c, 0x26, 0x5d, 0x26, 0xd0, 0x67, 0x5c, 0xe5, 0x5a, 0xf3, 0x15, 0x96, 0x3b, 0x3b, 0x78,
+	0x03, 0xe4, 0x60, 0xbc, 0x2a, 0xf2, 0x0d, 0xf4, 0x65, 0xb5, 0xae, 0x3e, 0x0e, 0x9f, 0x5c, 0xaf,
+	0xa1, 0xea, 0x04, 0xdd, 0x85, 0x07, 0x04, 0x3c, 0xfd, 0x61, 0x93, 0x95, 0x20, 0x75, 0x6a, 0x41,
+	0x02, 0x77, 0xbf, 0x8f, 0x96, 0x5c, 0xbc, 0x4b, 0x31, 0x4e, 0xd0, 0x68, 0xad, 0x29, 0x08, 0xe7,
+	0x40, 0x10, 0x56, 0x35, 0xad, 0x9b, 0xab, 0x71, 0xf7, 0xaa, 0xb9, 0xd7, 0x78, 0x48, 0xbf, 0xfd,
+	0xf4, 0x0f, 0x07, 0xee, 0x5f, 0x2b, 0x5e, 0x32, 0x84, 0x9e, 0x2a, 0xa2, 0x08, 0x95, 0xf2, 0xee,
+	0x90, 0x01, 0x74, 0x50, 0x4a, 0x21, 0x3d, 0x47, 0xfb, 0x17, 0x21, 0x4b, 0x0b, 0x89, 0x5e, 0x8b,
+	0x8c, 0xf4, 0x6b, 0x61, 0x94, 0xb3, 0x35, 0x7a, 0x2e, 0xb9, 0x0b, 0x43, 0xc6, 0x7f, 0xdf, 0xea,
+	0xc2, 0x6b, 0x13, 0x80, 0xee, 0xdb, 0x02, 0x0b, 0x8c, 0xbd, 0x8e, 0x3e, 0x97, 0x21, 0x8f, 0x19,
+	0x4f, 0xbc, 0x2e, 0x39, 0x82, 0x41, 0x39, 0x64, 0x53, 0x8c, 0xbd, 0xde, 0xd3, 0x33, 0x38, 0x6a,
+	0x90, 0x4a, 0xef, 0x17, 0x3c, 0xcc, 0xb2, 0x94, 0x61, 0xec, 0xdd, 0xd1, 0x67, 0xb7, 0x86, 0xa3,
+	0x2f, 0xd5, 0x09, 0x60, 0xec, 0xb5, 0xf4, 0xc6, 0x15, 0x86, 0x69, 0x7e, 0xb5, 0xf1, 0xdc, 0xd3,
+	0x7f, 0x5a, 0xd0, 0x2d, 0xab, 0x20, 0xaf, 0x61, 0x58, 0xd7, 0xa3, 0x48, 0xa3, 0x3d, 0x07, 0xdc,
+	0x98, 0xdc, 0xde, 0xbd, 0xcf, 0x1d, 0x72, 0x01, 0x23, 0xbb, 0x6b, 0xe4, 0xd6, 0xef, 0xca, 0xe4,
+	0xb8, 0x29, 0x96, 0x66, 0xb7, 0xc9, 0x39, 0x0c, 0xad, 0x26, 0x90, 0x87, 0x76, 0xf0, 0x1e, 0x0d,
+	0x26, 0x37, 0x6d, 0x9a, 0x8b, 0x7e, 0x85, 0xfb, 0xbf, 0xf0, 0x05, 0xe3, 0x4c, 0x5d, 0x61, 0xfc,
+	0x1e, 0xd5, 0x3e, 0xba, 0xb5, 0x5a, 0xf5, 0xf2, 0x25, 0xf8, 0x5c, 0x4c, 0x79, 0xb8, 0x2e, 0x27,
+	0xa6, 0xb2, 0xc2, 0x7f, 0x7b, 0x9c, 0x18, 0xae, 0x4c, 0x23, 0xb1, 0x9a, 0xf1, 0x70, 0xcd, 0x96,
+	0xf6, 0xbf, 0xe7, 0x2c, 0x5b, 0x26, 0xb3, 0x6c, 0xfe, 0x5d, 0x36, 0x9f, 0x77, 0xcd, 0xd1, 0x2f,
+	0xfe, 0x1b, 0x00, 0x73, 0xcf, 0x8f, 0xa0, 0xa5, 0x0a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -651,6 +1013,8 @@ const _ = grpc.SupportPackageIsVersion6
 type DeployClient interface {
 	Deployments(ctx context.Context, in *GetDeploymentOpts, opts ...grpc.CallOption) (Deploy_DeploymentsClient, error)
 	ReportStatus(ctx context.Context, in *DeploymentStatus, opts ...grpc.CallOption) (*ReportStatusOpts, error)
+	Acknowledge(ctx context.Context, in *Acknowledgement, opts ...grpc.CallOption) (*AcknowledgeOpts, error)
+	UnfinishedDeployments(ctx context.Context, in *GetDeploymentOpts, opts ...grpc.CallOption) (*DeploymentRequests, error)
 }
 
 type deployClient struct {
@@ -702,10 +1066,30 @@ func (c *deployClient) ReportStatus(ctx context.Context, in *DeploymentStatus, o
 	return out, nil
 }
 
+func (c *deployClient) Acknowledge(ctx context.Context, in *Acknowledgement, opts ...grpc.CallOption) (*AcknowledgeOpts, error) {
+	out := new(AcknowledgeOpts)
+	err := c.cc.Invoke(ctx, "/deployment.Deploy/Acknowledge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deployClient) UnfinishedDeployments(ctx context.Context, in *GetDeploymentOpts, opts ...grpc.CallOption) (*DeploymentRequests, error) {
+	out := new(DeploymentRequests)
+	err := c.cc.Invoke(ctx, "/deployment.Deploy/UnfinishedDeployments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DeployServer is the server API for Deploy service.
 type DeployServer interface {
 	Deployments(*GetDeploymentOpts, Deploy_DeploymentsServer) error
 	ReportStatus(context.Context, *DeploymentStatus) (*ReportStatusOpts, error)
+	Acknowledge(context.Context, *Acknowledgement) (*AcknowledgeOpts, error)
+	UnfinishedDeployments(context.Context, *GetDeploymentOpts) (*DeploymentRequests, error)
 }
 
 // UnimplementedDeployServer can be embedded to have forward compatible implementations.
@@ -718,6 +1102,12 @@ func (*UnimplementedDeployServer) Deployments(req *GetDeploymentOpts, srv Deploy
 func (*UnimplementedDeployServer) ReportStatus(ctx context.Context, req *DeploymentStatus) (*ReportStatusOpts, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReportStatus not implemented")
 }
+func (*UnimplementedDeployServer) Acknowledge(ctx context.Context, req *Acknowledgement) (*AcknowledgeOpts, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Acknowledge not implemented")
+}
+func (*UnimplementedDeployServer) UnfinishedDeployments(ctx context.Context, req *GetDeploymentOpts) (*DeploymentRequests, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnfinishedDeployments not implemented")
+}
 
 func RegisterDeployServer(s *grpc.Server, srv DeployServer) {
 	s.RegisterService(&_Deploy_serviceDesc, srv)
@@ -762,6 +1152,42 @@ func _Deploy_ReportStatus_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Deploy_Acknowledge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Acknowledgement)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeployServer).Acknowledge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/deployment.Deploy/Acknowledge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeployServer).Acknowledge(ctx, req.(*Acknowledgement))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deploy_UnfinishedDeployments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeploymentOpts)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeployServer).UnfinishedDeployments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/deployment.Deploy/UnfinishedDeployments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeployServer).UnfinishedDeployments(ctx, req.(*GetDeploymentOpts))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Deploy_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "deployment.Deploy",
 	HandlerType: (*DeployServer)(nil),
@@ -770,6 +1196,14 @@ var _Deploy_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ReportStatus",
 			Handler:    _Deploy_ReportStatus_Handler,
 		},
+		{
+			MethodName: "Acknowledge",
+			Handler:    _Deploy_Acknowledge_Handler,
+		},
+		{
+			MethodName: "UnfinishedDeployments",
+			Handler:    _Deploy_UnfinishedDeployments_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
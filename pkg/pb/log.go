@@ -14,6 +14,7 @@ const (
 	LogFieldEventType            = "event_type"
 	LogFieldDeploymentStatusID   = "deployment_status_id"
 	LogFieldDeploymentStatusType = "deployment_status"
+	LogFieldInstance             = "deployd_instance"
 )
 
 func (m *DeploymentStatus) LogFields() log.Fields {
@@ -38,3 +39,12 @@ func (m *DeploymentRequest) LogFields() log.Fields {
 		LogFieldRepository:    m.GetDeployment().GetRepository().FullName(),
 	}
 }
+
+func (m *Acknowledgement) LogFields() log.Fields {
+	return log.Fields{
+		LogFieldDeliveryID:    m.GetDeliveryID(), // deprecated
+		LogFieldCorrelationID: m.GetDeliveryID(),
+		LogFieldCluster:       m.GetCluster(),
+		LogFieldInstance:      m.GetInstance(),
+	}
+}
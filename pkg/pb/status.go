@@ -2,9 +2,23 @@ package pb
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Finished returns true if a deployment in this state will never change state again.
+func (x GithubDeploymentState) Finished() bool {
+	switch x {
+	case GithubDeploymentState_success,
+		GithubDeploymentState_error,
+		GithubDeploymentState_failure,
+		GithubDeploymentState_inactive,
+		GithubDeploymentState_cancelled:
+		return true
+	}
+	return false
+}
+
 func NewErrorStatus(req DeploymentRequest, err error) *DeploymentStatus {
 	return &DeploymentStatus{
 		Deployment:  req.Deployment,
@@ -64,3 +78,81 @@ func NewSuccessStatus(req DeploymentRequest) *DeploymentStatus {
 		Time:        TimeAsTimestamp(time.Now()),
 	}
 }
+
+func NewCancelledStatus(req DeploymentRequest) *DeploymentStatus {
+	return &DeploymentStatus{
+		Deployment:  req.GetDeployment(),
+		Description: "Deployment was cancelled",
+		State:       GithubDeploymentState_cancelled,
+		DeliveryID:  req.GetDeliveryID(),
+		Team:        req.GetPayloadSpec().GetTeam(),
+		Cluster:     req.GetCluster(),
+		Time:        TimeAsTimestamp(time.Now()),
+	}
+}
+
+func NewDryRunStatus(req DeploymentRequest, diff string, changed, total int) *DeploymentStatus {
+	return &DeploymentStatus{
+		Deployment:  req.GetDeployment(),
+		Description: fmt.Sprintf("Dry run completed; %d of %d resources would be changed", changed, total),
+		State:       GithubDeploymentState_success,
+		DeliveryID:  req.GetDeliveryID(),
+		Team:        req.GetPayloadSpec().GetTeam(),
+		Cluster:     req.GetCluster(),
+		Time:        TimeAsTimestamp(time.Now()),
+		Diff:        diff,
+	}
+}
+
+// NewRollingBackStatus reports that the rollout failed, and that the previous version of the resources is being restored.
+func NewRollingBackStatus(req DeploymentRequest, err error) *DeploymentStatus {
+	return &DeploymentStatus{
+		Deployment:  req.GetDeployment(),
+		Description: fmt.Sprintf("Deployment failed: %s; rolling back to previous version", err),
+		State:       GithubDeploymentState_in_progress,
+		DeliveryID:  req.GetDeliveryID(),
+		Team:        req.GetPayloadSpec().GetTeam(),
+		Cluster:     req.GetCluster(),
+		Time:        TimeAsTimestamp(time.Now()),
+	}
+}
+
+// NewRollbackStatus reports a failed deployment along with the outcome of rolling it back.
+func NewRollbackStatus(req DeploymentRequest, err, rollbackErr error) *DeploymentStatus {
+	description := fmt.Sprintf("Deployment failed: %s; rolled back to previous version", err)
+	if rollbackErr != nil {
+		description = fmt.Sprintf("Deployment failed: %s; rollback failed: %s", err, rollbackErr)
+	}
+	return &DeploymentStatus{
+		Deployment:  req.GetDeployment(),
+		Description: description,
+		State:       GithubDeploymentState_failure,
+		DeliveryID:  req.GetDeliveryID(),
+		Team:        req.GetPayloadSpec().GetTeam(),
+		Cluster:     req.GetCluster(),
+		Time:        TimeAsTimestamp(time.Now()),
+	}
+}
+
+// NewPrunedStatus reports a successful deployment that removed resources no longer part of the deployment.
+func NewPrunedStatus(req DeploymentRequest, pruned []string) *DeploymentStatus {
+	status := NewSuccessStatus(req)
+	if len(pruned) > 0 {
+		status.Description = fmt.Sprintf("%s Pruned %d resources no longer part of the deployment: %s", status.Description, len(pruned), strings.Join(pruned, ", "))
+	}
+	return status
+}
+
+// NewProgressStatus reports intermediate progress of a rollout. Progress statuses are not synchronized to GitHub.
+func NewProgressStatus(req DeploymentRequest, message string) *DeploymentStatus {
+	return &DeploymentStatus{
+		Deployment:  req.GetDeployment(),
+		Description: message,
+		State:       GithubDeploymentState_in_progress,
+		DeliveryID:  req.GetDeliveryID(),
+		Team:        req.GetPayloadSpec().GetTeam(),
+		Cluster:     req.GetCluster(),
+		Time:        TimeAsTimestamp(time.Now()),
+		Progress:    true,
+	}
+}
@@ -24,3 +24,9 @@ func (m *DeploymentRequest) Timestamp() time.Time {
 func (m *DeploymentStatus) Timestamp() time.Time {
 	return TimestampAsTime(m.GetTime())
 }
+
+// RolloutTimeout is how long deployd monitors the rollout of the deployed resources.
+// Zero means the request does not specify a timeout, and the deployd default applies.
+func (m *DeploymentRequest) RolloutTimeout() time.Duration {
+	return time.Duration(m.GetRolloutTimeoutSeconds()) * time.Second
+}